@@ -2,43 +2,55 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
-	"time"
+	"path/filepath"
 
 	"github.com/adrianliechti/wingman-index/pkg/config"
+	"github.com/adrianliechti/wingman-index/pkg/index"
 	"github.com/adrianliechti/wingman-index/pkg/indexer"
+	"github.com/adrianliechti/wingman-index/pkg/indexer/git"
+	"github.com/adrianliechti/wingman-index/pkg/indexer/local"
+	"github.com/adrianliechti/wingman-index/pkg/indexer/s3"
+	"github.com/adrianliechti/wingman-index/pkg/indexer/web"
 	"github.com/adrianliechti/wingman-index/pkg/server"
 )
 
 func main() {
 	ctx := context.Background()
 
-	root, err := os.Getwd()
+	cfg, err := config.FromEnvironment()
 
 	if err != nil {
 		panic(err)
 	}
 
-	cfg, err := config.FromEnvironment()
+	indexer, err := indexer.New(cfg)
 
 	if err != nil {
 		panic(err)
 	}
 
-	indexer, err := indexer.New(cfg)
+	defer indexer.Close()
 
-	if err != nil {
-		panic(err)
+	for _, s := range cfg.Sources {
+		if err := addSource(indexer, cfg, s); err != nil {
+			panic(err)
+		}
 	}
 
-	go func() {
-		for {
-			if err := indexer.IndexDir(ctx, root); err != nil {
-				log.Printf("Error indexing directory: %v\n", err)
-			}
+	if len(os.Args) > 1 && os.Args[1] == "rebuild" {
+		if err := indexer.Rebuild(ctx); err != nil {
+			log.Fatalf("Error rebuilding index: %v\n", err)
+		}
+
+		return
+	}
 
-			time.Sleep(5 * time.Minute)
+	go func() {
+		if err := indexer.Run(ctx); err != nil {
+			log.Printf("Error indexing: %v\n", err)
 		}
 	}()
 
@@ -48,3 +60,109 @@ func main() {
 		panic(err)
 	}
 }
+
+func addSource(idx *indexer.Indexer, cfg *config.Config, s config.Source) error {
+	var src indexer.Source
+
+	switch s.Type {
+	case "local":
+		path := s.Path
+
+		if path == "" {
+			path = "."
+		}
+
+		source, err := local.New(path, cfg.Files)
+
+		if err != nil {
+			return err
+		}
+
+		src = source
+
+	case "git":
+		url := s.URL
+
+		if url == "" {
+			url = s.Path
+		}
+
+		source, err := git.New(url,
+			git.WithReference(s.Ref),
+			git.WithToken(s.Token),
+			git.WithLink(s.Link),
+			git.WithFiles(cfg.Files),
+			git.WithDirectory(filepath.Join(cfg.CacheDir, "git", s.Name)),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		src = source
+
+	case "s3":
+		source, err := s3.New(s.Endpoint, s.Bucket,
+			s3.WithPrefix(s.Path),
+			s3.WithRegion(s.Region),
+			s3.WithCredentials(s.AccessKey, s.SecretKey),
+			s3.WithFiles(cfg.Files),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		src = source
+
+	case "web":
+		seeds := s.URLs
+
+		if s.URL != "" {
+			seeds = append([]string{s.URL}, seeds...)
+		}
+
+		options := []web.Option{
+			web.WithFiles(cfg.Files),
+		}
+
+		if s.Sitemap != "" {
+			options = append(options, web.WithSitemap(s.Sitemap))
+		}
+
+		if s.Depth != nil {
+			options = append(options, web.WithDepth(*s.Depth))
+		}
+
+		if s.Rate != nil {
+			options = append(options, web.WithRate(*s.Rate))
+		}
+
+		source, err := web.New(seeds, options...)
+
+		if err != nil {
+			return err
+		}
+
+		src = source
+
+	default:
+		return errors.New("invalid source type, expected one of: local, git, s3, web")
+	}
+
+	metadata := index.Metadata{}
+
+	for k, v := range s.Metadata {
+		metadata[k] = v
+	}
+
+	return idx.AddSource(indexer.SourceConfig{
+		Name:   s.Name,
+		Source: src,
+
+		Namespace: s.Namespace,
+		Prefix:    s.Prefix,
+
+		Metadata: metadata,
+	})
+}
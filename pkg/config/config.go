@@ -1,9 +1,15 @@
 package config
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/adrianliechti/wingman-index/pkg/index"
 	"github.com/adrianliechti/wingman-index/pkg/index/azure"
@@ -21,8 +27,49 @@ type Config struct {
 
 	Client *client.Client
 
+	// Namespace is the namespace or alias queries are served from
+	Namespace string
+
 	Index    index.Provider
 	Embedder index.Embedder
+
+	// BatchSize is the number of segments embedded per request
+	BatchSize int
+
+	// Segmenter selects how text is split into segments: "text" splits
+	// locally at headings, paragraphs and sentences, "wingman" uses the
+	// segmentation API
+	Segmenter string
+
+	// SegmentSize and SegmentOverlap are measured in characters, or in
+	// approximate tokens if SegmentTokens is set
+	SegmentSize    int
+	SegmentOverlap int
+	SegmentTokens  bool
+
+	// Concurrency is the number of files processed in parallel by each stage
+	// of the indexing pipeline, unless overridden per stage
+	ExtractConcurrency int
+	SegmentConcurrency int
+	EmbedConcurrency   int
+	UpsertConcurrency  int
+
+	// Watch enables indexing of file system changes as they happen
+	Watch bool
+
+	// Interval is the time between full indexing runs
+	Interval time.Duration
+
+	// CacheDir holds the state database with extracted content and embeddings
+	CacheDir string
+
+	// CacheRetention is how long cache entries no longer used by any item are
+	// kept; zero keeps them forever
+	CacheRetention time.Duration
+
+	Files Files
+
+	Sources []Source
 }
 
 func FromEnvironment() (*Config, error) {
@@ -44,19 +91,169 @@ func FromEnvironment() (*Config, error) {
 		return nil, err
 	}
 
-	index, err := indexFromEnvironment(embedder)
+	namespace := os.Getenv("INDEX_NAMESPACE")
+
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	batchSize, err := intFromEnvironment("INDEX_BATCH_SIZE", 32)
+
+	if err != nil {
+		return nil, err
+	}
+
+	segmenter := strings.ToLower(os.Getenv("INDEX_SEGMENTER"))
+
+	if segmenter == "" {
+		segmenter = "text"
+	}
+
+	if segmenter != "text" && segmenter != "wingman" {
+		return nil, errors.New("invalid segmenter type, expected one of: text, wingman")
+	}
+
+	segmentTokens := false
+
+	switch strings.ToLower(os.Getenv("INDEX_SEGMENT_UNIT")) {
+	case "", "characters", "chars":
+	case "tokens":
+		segmentTokens = true
+	default:
+		return nil, errors.New("invalid segment unit, expected one of: characters, tokens")
+	}
+
+	segmentSize, segmentOverlap := 2000, 200
+
+	if segmentTokens {
+		segmentSize, segmentOverlap = 500, 50
+	}
+
+	if segmenter == "wingman" {
+		segmentSize, segmentOverlap = 3000, 1500
+	}
+
+	if segmentSize, err = intFromEnvironment("INDEX_SEGMENT_SIZE", segmentSize); err != nil {
+		return nil, err
+	}
+
+	if segmentOverlap, err = intFromEnvironment("INDEX_SEGMENT_OVERLAP", segmentOverlap); err != nil {
+		return nil, err
+	}
+
+	concurrency, err := intFromEnvironment("INDEX_CONCURRENCY", 4)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stages := map[string]int{}
+
+	for _, stage := range []string{"EXTRACT", "SEGMENT", "EMBED", "UPSERT"} {
+		value, err := intFromEnvironment("INDEX_"+stage+"_CONCURRENCY", concurrency)
+
+		if err != nil {
+			return nil, err
+		}
+
+		stages[stage] = value
+	}
+
+	watch := os.Getenv("INDEX_WATCH") != "false"
+
+	interval := 5 * time.Minute
+
+	if watch {
+		interval = time.Hour
+	}
+
+	if value := os.Getenv("INDEX_INTERVAL"); value != "" {
+		if interval, err = time.ParseDuration(value); err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid INDEX_INTERVAL: %q", value)
+		}
+	}
+
+	wd, err := os.Getwd()
+
+	if err != nil {
+		return nil, err
+	}
+
+	cacheDir := filepath.Join(wd, ".cache")
+
+	if value := os.Getenv("INDEX_CACHE_DIR"); value != "" {
+		if cacheDir, err = filepath.Abs(value); err != nil {
+			return nil, err
+		}
+	}
+
+	cacheRetention := 7 * 24 * time.Hour
+
+	if value := os.Getenv("INDEX_CACHE_RETENTION"); value != "" {
+		if cacheRetention, err = time.ParseDuration(value); err != nil || cacheRetention < 0 {
+			return nil, fmt.Errorf("invalid INDEX_CACHE_RETENTION: %q", value)
+		}
+	}
+
+	file, err := configFileFromEnvironment()
+
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filesFromEnvironment(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sources, err := sourcesFromConfig(file, wd)
 
 	if err != nil {
 		return nil, err
 	}
 
+	index, err := indexFromEnvironment(namespace, embedder)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateIndex(context.Background(), index, embedder); err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
 		Address: addr,
 
 		Client: client,
 
+		Namespace: namespace,
+
 		Index:    index,
 		Embedder: embedder,
+
+		BatchSize: batchSize,
+
+		Segmenter:      segmenter,
+		SegmentSize:    segmentSize,
+		SegmentOverlap: segmentOverlap,
+		SegmentTokens:  segmentTokens,
+
+		ExtractConcurrency: stages["EXTRACT"],
+		SegmentConcurrency: stages["SEGMENT"],
+		EmbedConcurrency:   stages["EMBED"],
+		UpsertConcurrency:  stages["UPSERT"],
+
+		Watch:    watch,
+		Interval: interval,
+
+		CacheDir:       cacheDir,
+		CacheRetention: cacheRetention,
+
+		Files: *files,
+
+		Sources: sources,
 	}
 
 	return cfg, nil
@@ -82,37 +279,121 @@ func clientFromEnvironment() (*client.Client, error) {
 }
 
 func embedderFromEnvironment(client *client.Client) (index.Embedder, error) {
-	embeddingModel := os.Getenv("WINGMAN_EMBEDDER")
+	embeddingModel := os.Getenv("EMBEDDER_MODEL")
 
 	if embeddingModel == "" {
-		embeddingModel = "text-embedding-3-small"
+		embeddingModel = os.Getenv("WINGMAN_EMBEDDER")
+	}
+
+	dimensions, err := intFromEnvironment("EMBEDDER_DIMENSIONS", 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	batchSize, err := intFromEnvironment("EMBEDDER_BATCH_SIZE", 64)
+
+	if err != nil {
+		return nil, err
+	}
+
+	options := []utils.EmbedderOption{
+		utils.WithBatchSize(batchSize),
+	}
+
+	if token := os.Getenv("EMBEDDER_TOKEN"); token != "" {
+		options = append(options, utils.WithToken(token))
+	}
+
+	if dimensions > 0 {
+		options = append(options, utils.WithDimensions(dimensions))
+	}
+
+	var embedder index.Embedder
+
+	switch strings.ToLower(os.Getenv("EMBEDDER_TYPE")) {
+	case "", "wingman":
+		if embeddingModel == "" {
+			embeddingModel = "text-embedding-3-small"
+		}
+
+		embedder = utils.NewClientEmbedder(client, embeddingModel)
+
+	case "openai":
+		if embeddingModel == "" {
+			embeddingModel = "text-embedding-3-small"
+		}
+
+		embedder, err = utils.NewOpenAIEmbedder(os.Getenv("EMBEDDER_URL"), embeddingModel, options...)
+
+	case "ollama":
+		if embeddingModel == "" {
+			embeddingModel = "nomic-embed-text"
+		}
+
+		embedder, err = utils.NewOllamaEmbedder(os.Getenv("EMBEDDER_URL"), embeddingModel, options...)
+
+	default:
+		return nil, errors.New("invalid embedder type, expected one of: wingman, openai, ollama")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := floatFromEnvironment("EMBEDDER_RATE_LIMIT", 0)
+
+	if err != nil {
+		return nil, err
 	}
 
-	embedder := utils.NewClientEmbedder(client, embeddingModel)
+	retries, err := intFromEnvironment("EMBEDDER_RETRIES", 5)
+
+	if err != nil {
+		return nil, err
+	}
+
+	embedder = utils.NewLimitedEmbedder(embedder, limit, retries)
+
+	cacheSize, err := intFromEnvironment("EMBEDDER_CACHE_SIZE", 10000)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheDir := os.Getenv("EMBEDDER_CACHE_DIR"); cacheSize > 0 || cacheDir != "" {
+		cacheKey := embeddingModel
+
+		if dimensions > 0 {
+			cacheKey = fmt.Sprintf("%s@%d", embeddingModel, dimensions)
+		}
+
+		embedder = utils.NewCachingEmbedder(embedder, cacheKey, cacheSize, cacheDir)
+	}
 
 	return embedder, nil
 }
 
-func indexFromEnvironment(embedder index.Embedder) (index.Provider, error) {
+func indexFromEnvironment(namespace string, embedder index.Embedder) (index.Provider, error) {
 	switch strings.ToLower(os.Getenv("INDEX_TYPE")) {
 	case "azure":
-		return azureFromEnvironment()
+		return azureFromEnvironment(namespace)
 	case "chroma":
-		return chromaFromEnvironment(embedder)
+		return chromaFromEnvironment(namespace, embedder)
 	case "elasticsearch":
-		return elasticsearchFromEnvironment()
+		return elasticsearchFromEnvironment(namespace)
 	case "memory":
-		return memoryFromEnvironment(embedder)
+		return memoryFromEnvironment(namespace, embedder)
 	case "qdrant":
-		return qdrantFromEnvironment(embedder)
+		return qdrantFromEnvironment(namespace, embedder)
 	case "weaviate":
-		return weaviateFromEnvironment(embedder)
+		return weaviateFromEnvironment(namespace, embedder)
 	default:
 		return nil, errors.New("invalid index type, expected one of: azure, chroma, elasticsearch, memory, qdrant, weaviate")
 	}
 }
 
-func azureFromEnvironment() (index.Provider, error) {
+func azureFromEnvironment(namespace string) (index.Provider, error) {
 	url := os.Getenv("INDEX_URL")
 
 	if url == "" {
@@ -125,79 +406,123 @@ func azureFromEnvironment() (index.Provider, error) {
 		return nil, errors.New("INDEX_TOKEN environment variable is required for Azure index")
 	}
 
-	namespace := os.Getenv("INDEX_NAMESPACE")
-
-	if namespace == "" {
-		namespace = "default"
-	}
-
 	return azure.New(url, namespace, token)
 }
 
-func chromaFromEnvironment(embedder index.Embedder) (index.Provider, error) {
+func chromaFromEnvironment(namespace string, embedder index.Embedder) (index.Provider, error) {
 	url := os.Getenv("INDEX_URL")
 
 	if url == "" {
 		url = "http://localhost:8000"
 	}
 
-	namespace := os.Getenv("INDEX_NAMESPACE")
-
-	if namespace == "" {
-		namespace = "default"
-	}
-
 	return chroma.New(url, namespace, chroma.WithEmbedder(embedder))
 }
 
-func elasticsearchFromEnvironment() (index.Provider, error) {
+func elasticsearchFromEnvironment(namespace string) (index.Provider, error) {
 	url := os.Getenv("INDEX_URL")
 
 	if url == "" {
 		url = "http://localhost:6333"
 	}
 
-	namespace := os.Getenv("INDEX_NAMESPACE")
-
-	if namespace == "" {
-		namespace = "default"
-	}
-
 	return elasticsearch.New(url, namespace)
 }
 
-func memoryFromEnvironment(embedder index.Embedder) (index.Provider, error) {
-	return memory.New(memory.WithEmbedder(embedder))
+func memoryFromEnvironment(namespace string, embedder index.Embedder) (index.Provider, error) {
+	return memory.New(memory.WithEmbedder(embedder), memory.WithNamespace(namespace))
 }
 
-func qdrantFromEnvironment(embedder index.Embedder) (index.Provider, error) {
+func qdrantFromEnvironment(namespace string, embedder index.Embedder) (index.Provider, error) {
 	url := os.Getenv("INDEX_URL")
 
 	if url == "" {
 		url = "http://localhost:6333"
 	}
 
-	namespace := os.Getenv("INDEX_NAMESPACE")
-
-	if namespace == "" {
-		namespace = "default"
-	}
-
 	return qdrant.New(url, namespace, qdrant.WithEmbedder(embedder))
 }
 
-func weaviateFromEnvironment(embedder index.Embedder) (index.Provider, error) {
+func weaviateFromEnvironment(namespace string, embedder index.Embedder) (index.Provider, error) {
 	url := os.Getenv("INDEX_URL")
 
 	if url == "" {
 		url = "http://localhost:8080"
 	}
 
-	namespace := os.Getenv("INDEX_NAMESPACE")
+	return weaviate.New(url, namespace, weaviate.WithEmbedder(embedder))
+}
 
-	if namespace == "" {
-		namespace = "default"
+func validateIndex(ctx context.Context, p index.Provider, embedder index.Embedder) error {
+	s, ok := p.(index.StatsProvider)
+
+	if !ok {
+		return nil
 	}
 
-	return weaviate.New(url, namespace, weaviate.WithEmbedder(embedder))
+	stats, err := s.Stats(ctx)
+
+	if err != nil {
+		return fmt.Errorf("unable to get index stats: %w", err)
+	}
+
+	if stats.Dimension == 0 && stats.Model == "" {
+		return nil
+	}
+
+	embedding, err := embedder.Embed(ctx, []string{"init"})
+
+	if err != nil {
+		return fmt.Errorf("unable to probe embedder: %w", err)
+	}
+
+	var mismatch error
+
+	if stats.Model != "" && embedding.Model != "" && stats.Model != embedding.Model {
+		mismatch = fmt.Errorf("index was built with embedding model %q, but embedder uses %q", stats.Model, embedding.Model)
+	}
+
+	if dimension := len(embedding.Embeddings[0]); stats.Dimension > 0 && stats.Dimension != dimension {
+		mismatch = fmt.Errorf("index has embedding dimension %d, but embedder returns %d", stats.Dimension, dimension)
+	}
+
+	// the indexer re-embeds indexes it can recreate or rebuild
+	if _, ok := p.(index.NamespaceManager); ok && mismatch != nil {
+		log.Printf("%v, index will be re-embedded", mismatch)
+		return nil
+	}
+
+	return mismatch
+}
+
+func intFromEnvironment(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+
+	if value == "" {
+		return fallback, nil
+	}
+
+	result, err := strconv.Atoi(value)
+
+	if err != nil || result < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", key, value)
+	}
+
+	return result, nil
+}
+
+func floatFromEnvironment(key string, fallback float64) (float64, error) {
+	value := os.Getenv(key)
+
+	if value == "" {
+		return fallback, nil
+	}
+
+	result, err := strconv.ParseFloat(value, 64)
+
+	if err != nil || result < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", key, value)
+	}
+
+	return result, nil
 }
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Files selects which files below the root are indexed
+type Files struct {
+	// Extensions lists the indexed file extensions; the indexer uses its
+	// built-in list if empty
+	Extensions []string `yaml:"extensions"`
+
+	// Include and Exclude are .gitignore-style patterns relative to the root.
+	// If Include is set, only matching files are indexed.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+
+	// Hidden enables indexing of files and directories starting with a dot
+	Hidden bool `yaml:"hidden"`
+
+	// Symlinks enables following symbolic links
+	Symlinks bool `yaml:"symlinks"`
+
+	// MaxSize is the maximum file size in bytes, zero means unlimited
+	MaxSize int64 `yaml:"max_size"`
+}
+
+// configFile is the optional YAML file referenced by INDEX_CONFIG
+type configFile struct {
+	Files   Files    `yaml:"files"`
+	Sources []Source `yaml:"sources"`
+}
+
+func configFileFromEnvironment() (*configFile, error) {
+	file := &configFile{}
+
+	path := os.Getenv("INDEX_CONFIG")
+
+	if path == "" {
+		return file, nil
+	}
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// filesFromEnvironment overrides the file rules of the config file with the
+// individual environment variables
+func filesFromEnvironment(file *configFile) (*Files, error) {
+	files := &file.Files
+
+	if value := os.Getenv("INDEX_EXTENSIONS"); value != "" {
+		files.Extensions = nil
+
+		for _, ext := range splitList(value) {
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+
+			files.Extensions = append(files.Extensions, strings.ToLower(ext))
+		}
+	}
+
+	if value := os.Getenv("INDEX_INCLUDE"); value != "" {
+		files.Include = splitList(value)
+	}
+
+	if value := os.Getenv("INDEX_EXCLUDE"); value != "" {
+		files.Exclude = splitList(value)
+	}
+
+	if value := os.Getenv("INDEX_HIDDEN"); value != "" {
+		files.Hidden = value == "true"
+	}
+
+	if value := os.Getenv("INDEX_SYMLINKS"); value != "" {
+		files.Symlinks = value == "true"
+	}
+
+	if value := os.Getenv("INDEX_MAX_SIZE"); value != "" {
+		size, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid INDEX_MAX_SIZE: %q", value)
+		}
+
+		files.MaxSize = size
+	}
+
+	return files, nil
+}
+
+func splitList(value string) []string {
+	var result []string
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
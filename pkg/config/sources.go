@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Source configures a source of documents to index
+type Source struct {
+	Name string `yaml:"name"`
+
+	// Type selects the kind of source; defaults to "local"
+	Type string `yaml:"type"`
+
+	// Path is the directory of a local source, or the key prefix of an s3 source
+	Path string `yaml:"path"`
+
+	// URL is the remote or local path of a git repository, or a seed of a web source
+	URL string `yaml:"url"`
+
+	// URLs, Sitemap, Depth and Rate configure a web source: the seed URLs,
+	// a sitemap.xml to seed from, how many links to follow from the seeds and
+	// the requests per second
+	URLs    []string `yaml:"urls"`
+	Sitemap string   `yaml:"sitemap"`
+	Depth   *int     `yaml:"depth"`
+	Rate    *float64 `yaml:"rate"`
+
+	// Ref selects the branch, tag or commit of a git repository
+	Ref string `yaml:"ref"`
+
+	// Token authenticates against the remote of a git repository
+	Token string `yaml:"token"`
+
+	// Endpoint is the host or URL of an S3-compatible service
+	Endpoint string `yaml:"endpoint"`
+
+	// Bucket, Region and credentials of an s3 source; credentials default to
+	// the AWS_* environment variables
+	Bucket    string `yaml:"bucket"`
+	Region    string `yaml:"region"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+
+	// Link is the template of web UI links to the files of a git repository,
+	// using the placeholders {ref}, {commit} and {path}
+	Link string `yaml:"link"`
+
+	// Namespace overrides the namespace the documents are written to
+	Namespace string `yaml:"namespace"`
+
+	// Prefix is prepended to the paths of the documents
+	Prefix string `yaml:"prefix"`
+
+	// Metadata is added to all documents of the source
+	Metadata map[string]string `yaml:"metadata"`
+}
+
+// sourcesFromConfig returns the configured sources, or the working directory
+// as a single local source if there are none
+func sourcesFromConfig(file *configFile, wd string) ([]Source, error) {
+	if len(file.Sources) == 0 {
+		return []Source{
+			{
+				Name: "local",
+				Type: "local",
+				Path: wd,
+			},
+		}, nil
+	}
+
+	var result []Source
+
+	for i, s := range file.Sources {
+		if s.Type == "" {
+			s.Type = "local"
+		}
+
+		s.Type = strings.ToLower(s.Type)
+
+		if s.Name == "" {
+			return nil, fmt.Errorf("source %d: name is required", i+1)
+		}
+
+		if s.Prefix != "" && !strings.HasPrefix(s.Prefix, "/") {
+			s.Prefix = "/" + s.Prefix
+		}
+
+		result = append(result, s)
+	}
+
+	return result, nil
+}
@@ -13,7 +13,10 @@ import (
 )
 
 var (
-	_ index.Provider = (*Client)(nil)
+	_ index.Provider             = (*Client)(nil)
+	_ index.StatsProvider        = (*Client)(nil)
+	_ index.CapabilitiesProvider = (*Client)(nil)
+	_ index.NamespaceManager     = (*Client)(nil)
 )
 
 type Client struct {
@@ -117,6 +120,22 @@ func (c *Client) upsertCollection(ctx context.Context, name string) error {
 						"name": "value",
 						"type": "Edm.String",
 					},
+					{
+						"name": "number",
+						"type": "Edm.Double",
+					},
+					{
+						"name": "boolean",
+						"type": "Edm.Boolean",
+					},
+					{
+						"name": "date",
+						"type": "Edm.DateTimeOffset",
+					},
+					{
+						"name": "values",
+						"type": "Collection(Edm.String)",
+					},
 				},
 			},
 		},
@@ -2,7 +2,15 @@ package azure
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
 )
 
 func (c *Client) Delete(ctx context.Context, ids ...string) error {
@@ -42,3 +50,104 @@ func (c *Client) Delete(ctx context.Context, ids ...string) error {
 
 	return nil
 }
+
+func (c *Client) DeleteWhere(ctx context.Context, filter map[string]any) error {
+	if len(filter) == 0 {
+		return errors.New("filter is required")
+	}
+
+	if err := c.ensureCollection(ctx, c.namespace); err != nil {
+		return err
+	}
+
+	var ids []string
+
+	for {
+		page, err := c.searchIDs(ctx, convertFilter(filter), len(ids))
+
+		if err != nil {
+			return err
+		}
+
+		ids = append(ids, page...)
+
+		if len(page) < 1000 {
+			break
+		}
+	}
+
+	for chunk := range slices.Chunk(ids, 1000) {
+		if err := c.Delete(ctx, chunk...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) searchIDs(ctx context.Context, filter string, skip int) ([]string, error) {
+	queries := map[string]string{
+		"$filter": filter,
+		"$select": "id",
+
+		"$top":  "1000",
+		"$skip": fmt.Sprintf("%d", skip),
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", c.requestURL("/indexes/"+c.namespace+"/docs", queries), nil)
+	req.Header.Set("api-key", c.token)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	var result Results
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	var ids []string
+
+	for _, r := range result.Value {
+		ids = append(ids, r.ID())
+	}
+
+	return ids, nil
+}
+
+func convertFilter(filter map[string]any) string {
+	var conditions []string
+
+	for k, v := range filter {
+		var match string
+
+		switch v := index.NormalizeValue(v).(type) {
+		case bool:
+			match = fmt.Sprintf("m/boolean eq %t", v)
+		case int64, float64:
+			match = fmt.Sprintf("m/number eq %s", index.FormatValue(v))
+		case time.Time:
+			match = fmt.Sprintf("m/date eq %s", index.FormatValue(v))
+		default:
+			value := escapeFilter(index.FormatValue(v))
+			match = fmt.Sprintf("(m/value eq '%s' or m/values/any(v: v eq '%s'))", value, value)
+		}
+
+		conditions = append(conditions, fmt.Sprintf("metadata/any(m: m/key eq '%s' and %s)", escapeFilter(k), match))
+	}
+
+	return strings.Join(conditions, " and ")
+}
+
+func escapeFilter(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
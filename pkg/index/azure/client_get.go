@@ -0,0 +1,85 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+)
+
+func (c *Client) Get(ctx context.Context, ids ...string) ([]index.Document, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if err := c.ensureCollection(ctx, c.namespace); err != nil {
+		return nil, err
+	}
+
+	queries := map[string]string{
+		"$filter": idsFilter(ids),
+		"$top":    fmt.Sprintf("%d", len(ids)),
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", c.requestURL("/indexes/"+c.namespace+"/docs", queries), nil)
+	req.Header.Set("api-key", c.token)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	var result Results
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	var items []index.Document
+
+	for _, r := range result.Value {
+		items = append(items, index.Document{
+			ID: r.ID(),
+
+			Title:   r.Title(),
+			Source:  r.Source(),
+			Content: r.Content(),
+
+			Metadata: r.Metadata(),
+		})
+	}
+
+	return items, nil
+}
+
+// idsFilter matches documents by ID. IDs are listed in search.in separated by
+// '|'; the rare ID containing the delimiter is compared on its own.
+func idsFilter(ids []string) string {
+	var listed []string
+	var clauses []string
+
+	for _, id := range ids {
+		if strings.Contains(id, "|") {
+			clauses = append(clauses, fmt.Sprintf("id eq '%s'", escapeFilter(id)))
+			continue
+		}
+
+		listed = append(listed, id)
+	}
+
+	if len(listed) > 0 {
+		clauses = append(clauses, fmt.Sprintf("search.in(id, '%s', '|')", escapeFilter(strings.Join(listed, "|"))))
+	}
+
+	return strings.Join(clauses, " or ")
+}
@@ -0,0 +1,12 @@
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDsFilter(t *testing.T) {
+	require.Equal(t, "search.in(id, 'a,b|c', '|')", idsFilter([]string{"a,b", "c"}))
+	require.Equal(t, "id eq 'x|y' or search.in(id, 'z''s', '|')", idsFilter([]string{"x|y", "z's"}))
+}
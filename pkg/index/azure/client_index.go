@@ -3,6 +3,7 @@ package azure
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/adrianliechti/wingman-index/pkg/index"
 	"github.com/google/uuid"
@@ -33,13 +34,10 @@ func (c *Client) Index(ctx context.Context, documents ...index.Document) error {
 		}
 
 		if len(d.Metadata) > 0 {
-			metadata := []map[string]string{}
+			metadata := []map[string]any{}
 
 			for k, v := range d.Metadata {
-				metadata = append(metadata, map[string]string{
-					"key":   k,
-					"value": v,
-				})
+				metadata = append(metadata, convertMetadata(k, v))
 			}
 
 			item["metadata"] = metadata
@@ -68,3 +66,27 @@ func (c *Client) Index(ctx context.Context, documents ...index.Document) error {
 
 	return nil
 }
+
+// convertMetadata stores the textual value of every entry in "value" and
+// additionally fills the typed field matching the value type.
+func convertMetadata(key string, value any) map[string]any {
+	entry := map[string]any{
+		"key":   key,
+		"value": index.FormatValue(value),
+	}
+
+	switch v := index.NormalizeValue(value).(type) {
+	case bool:
+		entry["boolean"] = v
+	case int64:
+		entry["number"] = float64(v)
+	case float64:
+		entry["number"] = v
+	case time.Time:
+		entry["date"] = index.FormatValue(v)
+	case []string:
+		entry["values"] = v
+	}
+
+	return entry
+}
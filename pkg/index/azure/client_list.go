@@ -2,25 +2,81 @@ package azure
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
 
 	"github.com/adrianliechti/wingman-index/pkg/index"
 )
 
 func (c *Client) List(ctx context.Context, options *index.ListOptions) (*index.Page[index.Document], error) {
-	results, err := c.Query(ctx, "*", &index.QueryOptions{})
+	if options == nil {
+		options = new(index.ListOptions)
+	}
+
+	limit := 1000
+
+	if options.Limit != nil {
+		limit = *options.Limit
+	}
+
+	// documents are listed in the order of their IDs; the cursor is the last
+	// ID of a page, which unlike $skip is not limited to the first results
+	queries := map[string]string{
+		"search":   "*",
+		"$orderby": "id asc",
+
+		"$top": fmt.Sprintf("%d", limit),
+	}
+
+	if options.Cursor != "" {
+		queries["$filter"] = fmt.Sprintf("id gt '%s'", escapeFilter(options.Cursor))
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", c.requestURL("/indexes/"+c.namespace+"/docs", queries), nil)
+	req.Header.Set("api-key", c.token)
+
+	resp, err := c.client.Do(req)
 
 	if err != nil {
 		return nil, err
 	}
 
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	var result Results
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
 	var items []index.Document
 
-	for _, r := range results {
-		items = append(items, r.Document)
+	for _, r := range result.Value {
+		items = append(items, index.Document{
+			ID: r.ID(),
+
+			Title:   r.Title(),
+			Source:  r.Source(),
+			Content: r.Content(),
+
+			Metadata: r.Metadata(),
+		})
+	}
+
+	var cursor string
+
+	if len(items) == limit {
+		cursor = items[len(items)-1].ID
 	}
 
 	page := index.Page[index.Document]{
-		Items: items,
+		Items:  items,
+		Cursor: cursor,
 	}
 
 	return &page, nil
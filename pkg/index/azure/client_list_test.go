@@ -0,0 +1,64 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+	"github.com/adrianliechti/wingman-index/pkg/to"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPages(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		require.Equal(t, "id asc", query.Get("$orderby"))
+
+		after := strings.TrimSuffix(strings.TrimPrefix(query.Get("$filter"), "id gt '"), "'")
+
+		var top int
+		fmt.Sscanf(query.Get("$top"), "%d", &top)
+
+		var values []string
+
+		for _, id := range ids {
+			if id > after && len(values) < top {
+				values = append(values, fmt.Sprintf(`{"id": %q}`, id))
+			}
+		}
+
+		fmt.Fprintf(w, `{"value": [%s]}`, strings.Join(values, ","))
+	}))
+
+	defer server.Close()
+
+	c, err := New(server.URL, "test", "token")
+	require.NoError(t, err)
+
+	var result []string
+
+	var cursor string
+
+	for {
+		page, err := c.List(context.Background(), &index.ListOptions{Limit: to.Ptr(2), Cursor: cursor})
+		require.NoError(t, err)
+
+		for _, d := range page.Items {
+			result = append(result, d.ID)
+		}
+
+		if cursor = page.Cursor; cursor == "" {
+			break
+		}
+	}
+
+	require.Equal(t, ids, result)
+}
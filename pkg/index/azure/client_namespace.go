@@ -0,0 +1,83 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+)
+
+func (c *Client) Namespaces(ctx context.Context) ([]index.Namespace, error) {
+	queries := map[string]string{
+		"$select": "name",
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", c.requestURL("/indexes", queries), nil)
+	req.Header.Set("api-key", c.token)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	var result Indexes
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	var namespaces []index.Namespace
+
+	for _, i := range result.Value {
+		namespaces = append(namespaces, index.Namespace{
+			Name: i.Name,
+		})
+	}
+
+	return namespaces, nil
+}
+
+func (c *Client) CreateNamespace(ctx context.Context, name string, options *index.NamespaceOptions) error {
+	return c.upsertCollection(ctx, name)
+}
+
+func (c *Client) DropNamespace(ctx context.Context, name string) error {
+	req, _ := http.NewRequestWithContext(ctx, "DELETE", c.requestURL("/indexes/"+name, nil), nil)
+	req.Header.Set("api-key", c.token)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		return convertError(resp)
+	}
+
+	return nil
+}
+
+// AliasNamespace is not supported, index aliases are only available in
+// preview versions of the Azure AI Search API.
+func (c *Client) AliasNamespace(ctx context.Context, alias, namespace string) error {
+	return errors.ErrUnsupported
+}
+
+func (c *Client) WithNamespace(name string) index.Provider {
+	client := *c
+	client.namespace = name
+
+	return &client
+}
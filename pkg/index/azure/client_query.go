@@ -3,6 +3,7 @@ package azure
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -27,6 +28,10 @@ func (c *Client) Query(ctx context.Context, query string, options *index.QueryOp
 		queries["$top"] = fmt.Sprintf("%d", *options.Limit)
 	}
 
+	if len(options.Filters) > 0 {
+		queries["$filter"] = convertFilter(options.Filters)
+	}
+
 	req, _ := http.NewRequestWithContext(ctx, "GET", c.requestURL("/indexes/"+c.namespace+"/docs", queries), nil)
 	req.Header.Set("api-key", c.token)
 
@@ -38,6 +43,10 @@ func (c *Client) Query(ctx context.Context, query string, options *index.QueryOp
 
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
 	var result Results
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
@@ -64,3 +73,23 @@ func (c *Client) Query(ctx context.Context, query string, options *index.QueryOp
 
 	return results, nil
 }
+
+func (c *Client) QueryVector(ctx context.Context, vector []float32, options *index.QueryOptions) ([]index.Result, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (c *Client) QueryBatch(ctx context.Context, queries []string, options *index.QueryOptions) ([][]index.Result, error) {
+	results := make([][]index.Result, len(queries))
+
+	for i, query := range queries {
+		r, err := c.Query(ctx, query, options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		results[i] = r
+	}
+
+	return results, nil
+}
@@ -0,0 +1,37 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryFilters(t *testing.T) {
+	var filter string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter = r.URL.Query().Get("$filter")
+
+		w.Write([]byte(`{"value": [{"id": "1", "metadata": [{"key": "language", "value": "go"}]}]}`))
+	}))
+
+	defer server.Close()
+
+	c, err := New(server.URL, "test", "token")
+	require.NoError(t, err)
+
+	results, err := c.Query(context.Background(), "hello", &index.QueryOptions{
+		Filters: map[string]any{"language": "go"},
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, convertFilter(map[string]any{"language": "go"}), filter)
+
+	require.Len(t, results, 1)
+	require.Equal(t, index.Metadata{"language": "go"}, results[0].Metadata)
+}
@@ -0,0 +1,49 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+)
+
+func (c *Client) Stats(ctx context.Context) (*index.Stats, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", c.requestURL("/indexes/"+c.namespace+"/stats", nil), nil)
+	req.Header.Set("api-key", c.token)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &index.Stats{}, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	var result Stats
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &index.Stats{
+		Documents: result.DocumentCount,
+
+		Size: result.StorageSize,
+	}, nil
+}
+
+func (c *Client) Capabilities() index.Capabilities {
+	return index.Capabilities{
+		Filters: true,
+		Paging:  true,
+	}
+}
@@ -1,9 +1,24 @@
 package azure
 
+import (
+	"github.com/adrianliechti/wingman-index/pkg/index"
+)
+
 type Results struct {
 	Value []Result `json:"value"`
 }
 
+type Indexes struct {
+	Value []struct {
+		Name string `json:"name"`
+	} `json:"value"`
+}
+
+type Stats struct {
+	DocumentCount int64 `json:"documentCount"`
+	StorageSize   int64 `json:"storageSize"`
+}
+
 type Result map[string]any
 
 func (r Result) ID() string {
@@ -46,7 +61,7 @@ func (r Result) Source() string {
 	return ""
 }
 
-func (r Result) Metadata() map[string]string {
+func (r Result) Metadata() index.Metadata {
 	if val := r.Map("metadata"); val != nil {
 		return val
 	}
@@ -70,7 +85,7 @@ func (r Result) String(name string) string {
 	return data
 }
 
-func (r Result) Map(name string) map[string]string {
+func (r Result) Map(name string) index.Metadata {
 	val, ok := r[name]
 
 	if !ok {
@@ -87,7 +102,7 @@ func (r Result) Map(name string) map[string]string {
 		return nil
 	}
 
-	result := map[string]string{}
+	result := index.Metadata{}
 
 	for _, item := range slice {
 		entry, ok := item.(map[string]interface{})
@@ -96,14 +111,32 @@ func (r Result) Map(name string) map[string]string {
 			continue
 		}
 
-		key := entry["key"].(string)
-		value := entry["value"].(string)
+		key, _ := entry["key"].(string)
 
 		if key == "" {
 			continue
 		}
 
-		result[key] = value
+		var value any = entry["value"]
+
+		for _, field := range []string{"boolean", "number", "date", "values"} {
+			v, ok := entry[field]
+
+			if !ok || v == nil {
+				continue
+			}
+
+			if values, ok := v.([]interface{}); ok && len(values) == 0 {
+				continue
+			}
+
+			value = v
+			break
+		}
+
+		if value = index.NormalizeValue(value); value != nil {
+			result[key] = value
+		}
 	}
 
 	return result
@@ -8,13 +8,19 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/adrianliechti/wingman-index/pkg/index"
 
 	"github.com/google/uuid"
 )
 
-var _ index.Provider = &Client{}
+var (
+	_ index.Provider             = &Client{}
+	_ index.StatsProvider        = &Client{}
+	_ index.CapabilitiesProvider = &Client{}
+	_ index.NamespaceManager     = &Client{}
+)
 
 type Client struct {
 	client *http.Client
@@ -84,42 +90,49 @@ func (c *Client) List(ctx context.Context, options *index.ListOptions) (*index.P
 		return nil, err
 	}
 
-	items := make([]index.Document, 0)
+	page := index.Page[index.Document]{
+		Items: convertDocuments(result),
+	}
 
-	for i := range result.IDs {
-		id := result.IDs[i]
-		content := result.Documents[i]
+	return &page, nil
+}
 
-		metadata := result.Metadatas[i]
+func (c *Client) Get(ctx context.Context, ids ...string) ([]index.Document, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
 
-		if metadata == nil {
-			metadata = make(map[string]string)
-		}
+	col, err := c.createCollection(c.namespace)
 
-		title := metadata["_title"]
-		delete(metadata, "_title")
+	if err != nil {
+		return nil, err
+	}
 
-		source := metadata["_source"]
-		delete(metadata, "_source")
+	u, _ := url.JoinPath(c.url, "/api/v1/collections/"+col.ID+"/get")
 
-		d := index.Document{
-			ID: id,
+	body := map[string]any{
+		"ids": ids,
+	}
 
-			Title:   title,
-			Source:  source,
-			Content: content,
+	resp, err := c.client.Post(u, "application/json", jsonReader(body))
 
-			Metadata: metadata,
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		items = append(items, d)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
 	}
 
-	page := index.Page[index.Document]{
-		Items: items,
+	var result getResult
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
 	}
 
-	return &page, nil
+	return convertDocuments(result), nil
 }
 
 func (c *Client) Index(ctx context.Context, documents ...index.Document) error {
@@ -141,7 +154,7 @@ func (c *Client) Index(ctx context.Context, documents ...index.Document) error {
 		Embeddings: make([][]float32, len(documents)),
 
 		Documents: make([]string, len(documents)),
-		Metadatas: make([]map[string]string, len(documents)),
+		Metadatas: make([]map[string]any, len(documents)),
 	}
 
 	for i, d := range documents {
@@ -149,11 +162,10 @@ func (c *Client) Index(ctx context.Context, documents ...index.Document) error {
 			d.ID = uuid.NewString()
 		}
 
-		metadata := d.Metadata
+		metadata := convertMetadata(d.Metadata)
 
-		if metadata == nil {
-			metadata = make(map[string]string)
-		}
+		metadata["_title"] = d.Title
+		metadata["_source"] = d.Source
 
 		if len(d.Embedding) == 0 && c.embedder != nil {
 			embedding, err := c.embedder.Embed(ctx, []string{d.Content})
@@ -216,29 +228,87 @@ func (c *Client) Delete(ctx context.Context, ids ...string) error {
 	return nil
 }
 
-func (c *Client) Query(ctx context.Context, query string, options *index.QueryOptions) ([]index.Result, error) {
-	if options == nil {
-		options = &index.QueryOptions{}
+func (c *Client) DeleteWhere(ctx context.Context, filter map[string]any) error {
+	if len(filter) == 0 {
+		return errors.New("filter is required")
 	}
 
 	col, err := c.createCollection(c.namespace)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
+	u, _ := url.JoinPath(c.url, "/api/v1/collections/"+col.ID+"/delete")
+
+	body := map[string]any{
+		"where": convertFilter(filter),
+	}
+
+	resp, err := c.client.Post(u, "application/json", jsonReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return convertError(resp)
+	}
+
+	return nil
+}
+
+func (c *Client) Query(ctx context.Context, query string, options *index.QueryOptions) ([]index.Result, error) {
 	embedding, err := c.embedder.Embed(ctx, []string{query})
 
 	if err != nil {
 		return nil, err
 	}
 
+	return c.QueryVector(ctx, embedding.Embeddings[0], options)
+}
+
+func (c *Client) QueryVector(ctx context.Context, vector []float32, options *index.QueryOptions) ([]index.Result, error) {
+	results, err := c.queryEmbeddings(ctx, [][]float32{vector}, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return results[0], nil
+}
+
+func (c *Client) QueryBatch(ctx context.Context, queries []string, options *index.QueryOptions) ([][]index.Result, error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	embedding, err := c.embedder.Embed(ctx, queries)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.queryEmbeddings(ctx, embedding.Embeddings, options)
+}
+
+func (c *Client) queryEmbeddings(ctx context.Context, vectors [][]float32, options *index.QueryOptions) ([][]index.Result, error) {
+	if options == nil {
+		options = &index.QueryOptions{}
+	}
+
+	col, err := c.createCollection(c.namespace)
+
+	if err != nil {
+		return nil, err
+	}
+
 	u, _ := url.JoinPath(c.url, "/api/v1/collections/"+col.ID+"/query")
 
 	body := map[string]any{
-		"query_embeddings": [][]float32{
-			embedding.Embeddings[0],
-		},
+		"query_embeddings": vectors,
 
 		"include": []string{
 			"documents",
@@ -248,7 +318,7 @@ func (c *Client) Query(ctx context.Context, query string, options *index.QueryOp
 	}
 
 	if len(options.Filters) > 0 {
-		body["where"] = options.Filters
+		body["where"] = convertFilter(options.Filters)
 	}
 
 	if options.Limit != nil {
@@ -273,27 +343,23 @@ func (c *Client) Query(ctx context.Context, query string, options *index.QueryOp
 		return nil, err
 	}
 
-	results := make([]index.Result, 0)
+	results := make([][]index.Result, len(vectors))
 
 	for i := range result.IDs {
+		if i >= len(results) {
+			break
+		}
+
+		results[i] = make([]index.Result, 0)
+
 		for j := range result.IDs[i] {
 			id := result.IDs[i][j]
 
 			content := result.Documents[i][j]
-			metadata := result.Metadatas[i][j]
+			title, source, metadata := parseMetadata(result.Metadatas[i][j])
 
 			score := 1 - result.Distances[i][j]
 
-			if metadata == nil {
-				metadata = make(map[string]string)
-			}
-
-			title := metadata["_title"]
-			delete(metadata, "_title")
-
-			source := metadata["_source"]
-			delete(metadata, "_source")
-
 			r := index.Result{
 				Score: score,
 
@@ -308,13 +374,60 @@ func (c *Client) Query(ctx context.Context, query string, options *index.QueryOp
 				},
 			}
 
-			results = append(results, r)
+			results[i] = append(results[i], r)
 		}
 	}
 
 	return results, nil
 }
 
+func (c *Client) Stats(ctx context.Context) (*index.Stats, error) {
+	col, err := c.createCollection(c.namespace)
+
+	if err != nil {
+		return nil, err
+	}
+
+	u, _ := url.JoinPath(c.url, "/api/v1/collections/"+col.ID+"/count")
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	var count int64
+
+	if err := json.NewDecoder(resp.Body).Decode(&count); err != nil {
+		return nil, err
+	}
+
+	stats := &index.Stats{
+		Documents: count,
+	}
+
+	if col.Dimension != nil {
+		stats.Dimension = *col.Dimension
+	}
+
+	return stats, nil
+}
+
+func (c *Client) Capabilities() index.Capabilities {
+	return index.Capabilities{
+		Filters: true,
+		Vectors: true,
+	}
+}
+
 func (c *Client) createCollection(name string) (*collection, error) {
 	u, _ := url.JoinPath(c.url, "/api/v1/collections")
 
@@ -344,6 +457,117 @@ func (c *Client) createCollection(name string) (*collection, error) {
 	return &result, nil
 }
 
+func convertDocuments(result getResult) []index.Document {
+	items := make([]index.Document, 0)
+
+	for i := range result.IDs {
+		id := result.IDs[i]
+		content := result.Documents[i]
+
+		title, source, metadata := parseMetadata(result.Metadatas[i])
+
+		d := index.Document{
+			ID: id,
+
+			Title:   title,
+			Source:  source,
+			Content: content,
+
+			Metadata: metadata,
+		}
+
+		items = append(items, d)
+	}
+
+	return items
+}
+
+// convertMetadata maps metadata to chroma scalars; times are stored as
+// RFC 3339 strings and lists as comma separated strings. As chroma cannot
+// match list elements, each element is also stored as a member key.
+func convertMetadata(metadata index.Metadata) map[string]any {
+	result := make(map[string]any, len(metadata)+2)
+
+	for k, v := range metadata {
+		switch v := index.NormalizeValue(v).(type) {
+		case nil:
+			continue
+		case string, bool, int64, float64:
+			result[k] = v
+		case []string:
+			result[k] = index.FormatValue(v)
+
+			for _, e := range v {
+				result[memberKey(k, e)] = true
+			}
+		default:
+			result[k] = index.FormatValue(v)
+		}
+	}
+
+	return result
+}
+
+func parseMetadata(values map[string]any) (string, string, index.Metadata) {
+	metadata := index.NormalizeMetadata(values)
+
+	if metadata == nil {
+		metadata = make(index.Metadata)
+	}
+
+	for k := range metadata {
+		if strings.HasPrefix(k, memberPrefix) {
+			delete(metadata, k)
+		}
+	}
+
+	title := metadata.String("_title")
+	delete(metadata, "_title")
+
+	source := metadata.String("_source")
+	delete(metadata, "_source")
+
+	return title, source, metadata
+}
+
+// memberPrefix marks the keys storing the elements of list values
+const memberPrefix = "_in:"
+
+func memberKey(key, value string) string {
+	return memberPrefix + key + ":" + value
+}
+
+// convertFilter matches scalar values for equality and list values by their
+// member keys
+func convertFilter(filter map[string]any) map[string]any {
+	var conditions []map[string]any
+
+	for k, v := range filter {
+		condition := map[string]any{
+			k: index.EncodeValue(v),
+		}
+
+		if s, ok := v.(string); ok {
+			condition = map[string]any{
+				"$or": []map[string]any{
+					condition,
+					{memberKey(k, s): true},
+				},
+			}
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+
+	return map[string]any{
+		"$and": conditions,
+	}
+}
+
 func convertError(resp *http.Response) error {
 	type resultType struct {
 		Errors []errorDetail `json:"detail"`
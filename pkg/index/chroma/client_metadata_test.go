@@ -0,0 +1,26 @@
+package chroma
+
+import (
+	"testing"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListMetadata(t *testing.T) {
+	values := convertMetadata(index.Metadata{"tags": []string{"go", "rust"}})
+
+	require.Equal(t, "go,rust", values["tags"])
+	require.Equal(t, true, values[memberKey("tags", "go")])
+
+	require.Equal(t, map[string]any{
+		"$or": []map[string]any{
+			{"tags": "go"},
+			{memberKey("tags", "go"): true},
+		},
+	}, convertFilter(map[string]any{"tags": "go"}))
+
+	_, _, metadata := parseMetadata(values)
+	require.Equal(t, index.Metadata{"tags": "go,rust"}, metadata)
+}
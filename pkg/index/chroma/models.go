@@ -8,6 +8,8 @@ type collection struct {
 
 	Name     string         `json:"name,omitempty"`
 	Metadata map[string]any `json:"metadata,omitempty"`
+
+	Dimension *int `json:"dimension,omitempty"`
 }
 
 type embeddings struct {
@@ -15,8 +17,8 @@ type embeddings struct {
 
 	Embeddings [][]float32 `json:"embeddings"`
 
-	Metadatas []map[string]string `json:"metadatas"`
-	Documents []string            `json:"documents"`
+	Metadatas []map[string]any `json:"metadatas"`
+	Documents []string         `json:"documents"`
 }
 
 type getResult struct {
@@ -26,8 +28,8 @@ type getResult struct {
 
 	Embeddings [][]float64 `json:"embeddings"`
 
-	Metadatas []map[string]string `json:"metadatas"`
-	Documents []string            `json:"documents"`
+	Metadatas []map[string]any `json:"metadatas"`
+	Documents []string         `json:"documents"`
 }
 
 type queryResult struct {
@@ -37,8 +39,8 @@ type queryResult struct {
 
 	Embeddings [][][]float64 `json:"embeddings"`
 
-	Metadatas [][]map[string]string `json:"metadatas"`
-	Documents [][]string            `json:"documents"`
+	Metadatas [][]map[string]any `json:"metadatas"`
+	Documents [][]string         `json:"documents"`
 }
 
 type errorDetail struct {
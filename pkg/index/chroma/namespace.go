@@ -0,0 +1,82 @@
+package chroma
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+)
+
+func (c *Client) Namespaces(ctx context.Context) ([]index.Namespace, error) {
+	u, _ := url.JoinPath(c.url, "/api/v1/collections")
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	var result []collection
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	var namespaces []index.Namespace
+
+	for _, col := range result {
+		namespaces = append(namespaces, index.Namespace{
+			Name: col.Name,
+		})
+	}
+
+	return namespaces, nil
+}
+
+func (c *Client) CreateNamespace(ctx context.Context, name string, options *index.NamespaceOptions) error {
+	_, err := c.createCollection(name)
+	return err
+}
+
+func (c *Client) DropNamespace(ctx context.Context, name string) error {
+	u, _ := url.JoinPath(c.url, "/api/v1/collections/"+name)
+
+	req, _ := http.NewRequestWithContext(ctx, "DELETE", u, nil)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return convertError(resp)
+	}
+
+	return nil
+}
+
+// AliasNamespace is not supported, chroma has no collection aliases.
+func (c *Client) AliasNamespace(ctx context.Context, alias, namespace string) error {
+	return errors.ErrUnsupported
+}
+
+func (c *Client) WithNamespace(name string) index.Provider {
+	client := *c
+	client.namespace = name
+
+	return &client
+}
@@ -14,7 +14,12 @@ import (
 	"github.com/google/uuid"
 )
 
-var _ index.Provider = &Client{}
+var (
+	_ index.Provider             = &Client{}
+	_ index.StatsProvider        = &Client{}
+	_ index.CapabilitiesProvider = &Client{}
+	_ index.NamespaceManager     = &Client{}
+)
 
 type Client struct {
 	client *http.Client
@@ -49,17 +54,34 @@ func New(url, namespace string, options ...Option) (*Client, error) {
 }
 
 func (c *Client) List(ctx context.Context, options *index.ListOptions) (*index.Page[index.Document], error) {
+	if options == nil {
+		options = new(index.ListOptions)
+	}
+
+	limit := 1000
+
+	if options.Limit != nil {
+		limit = *options.Limit
+	}
+
 	u, _ := url.JoinPath(c.url, "/"+c.namespace+"/_search")
 
+	// documents are sorted by ID and the cursor is the last ID of a page;
+	// unlike from/size, search_after is not limited to the first results
 	body := map[string]any{
-		"size": 10000,
-		"from": 0,
+		"size": limit,
+		"sort": []any{"id"},
+
 		"query": map[string]any{
 			"match_all": map[string]any{},
 		},
 	}
 
-	req, _ := http.NewRequestWithContext(ctx, "GET", u, jsonReader(body))
+	if options.Cursor != "" {
+		body["search_after"] = []any{options.Cursor}
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, jsonReader(body))
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.client.Do(req)
@@ -68,6 +90,8 @@ func (c *Client) List(ctx context.Context, options *index.ListOptions) (*index.P
 		return nil, err
 	}
 
+	defer resp.Body.Close()
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, convertError(resp)
 	}
@@ -88,22 +112,96 @@ func (c *Client) List(ctx context.Context, options *index.ListOptions) (*index.P
 			Source:  hit.Document.Source,
 			Content: hit.Document.Content,
 
-			Metadata: hit.Document.Metadata,
+			Metadata: index.NormalizeMetadata(hit.Document.Metadata),
 		})
 	}
 
+	var cursor string
+
+	if len(items) == limit {
+		cursor = items[len(items)-1].ID
+	}
+
 	page := index.Page[index.Document]{
-		Items: items,
+		Items:  items,
+		Cursor: cursor,
 	}
 
 	return &page, nil
 }
 
+func (c *Client) Get(ctx context.Context, ids ...string) ([]index.Document, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var keys []string
+
+	for _, id := range ids {
+		keys = append(keys, convertID(id))
+	}
+
+	u, _ := url.JoinPath(c.url, "/"+c.namespace+"/_mget")
+
+	body := map[string]any{
+		"ids": keys,
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, jsonReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	var result GetResult
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	var items []index.Document
+
+	for _, doc := range result.Docs {
+		if !doc.Found {
+			continue
+		}
+
+		items = append(items, index.Document{
+			ID: doc.Document.ID,
+
+			Title:   doc.Document.Title,
+			Source:  doc.Document.Source,
+			Content: doc.Document.Content,
+
+			Metadata: index.NormalizeMetadata(doc.Document.Metadata),
+		})
+	}
+
+	return items, nil
+}
+
 func (c *Client) Index(ctx context.Context, documents ...index.Document) error {
 	if len(documents) == 0 {
 		return nil
 	}
 
+	if err := c.ensureIndex(ctx); err != nil {
+		return err
+	}
+
 	for _, d := range documents {
 		if d.ID == "" {
 			d.ID = uuid.NewString()
@@ -116,7 +214,7 @@ func (c *Client) Index(ctx context.Context, documents ...index.Document) error {
 			Source:  d.Source,
 			Content: d.Content,
 
-			Metadata: d.Metadata,
+			Metadata: d.Metadata.Values(),
 		}
 
 		u, _ := url.JoinPath(c.url, "/"+c.namespace+"/_doc/"+convertID(d.ID))
@@ -160,19 +258,48 @@ func (c *Client) Delete(ctx context.Context, ids ...string) error {
 	return result
 }
 
-func (c *Client) Query(ctx context.Context, query string, options *index.QueryOptions) ([]index.Result, error) {
-	u, _ := url.JoinPath(c.url, "/"+c.namespace+"/_search")
+func (c *Client) DeleteWhere(ctx context.Context, filter map[string]any) error {
+	if len(filter) == 0 {
+		return errors.New("filter is required")
+	}
+
+	u, _ := url.JoinPath(c.url, "/"+c.namespace+"/_delete_by_query")
 
 	body := map[string]any{
 		"query": map[string]any{
-			"multi_match": map[string]any{
-				"query":    query,
-				"fields":   []string{"content", "metadata.*"},
-				"analyzer": "english",
+			"bool": map[string]any{
+				"filter": convertFilter(filter),
 			},
 		},
 	}
 
+	req, _ := http.NewRequestWithContext(ctx, "POST", u+"?refresh=true", jsonReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return convertError(resp)
+	}
+
+	return nil
+}
+
+func (c *Client) Query(ctx context.Context, query string, options *index.QueryOptions) ([]index.Result, error) {
+	u, _ := url.JoinPath(c.url, "/"+c.namespace+"/_search")
+
+	body := searchBody(query, options)
+
 	req, _ := http.NewRequestWithContext(ctx, "GET", u, jsonReader(body))
 	req.Header.Set("Content-Type", "application/json")
 
@@ -182,6 +309,8 @@ func (c *Client) Query(ctx context.Context, query string, options *index.QueryOp
 		return nil, err
 	}
 
+	defer resp.Body.Close()
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, convertError(resp)
 	}
@@ -192,6 +321,234 @@ func (c *Client) Query(ctx context.Context, query string, options *index.QueryOp
 		return nil, err
 	}
 
+	return convertResults(result), nil
+}
+
+func (c *Client) QueryVector(ctx context.Context, vector []float32, options *index.QueryOptions) ([]index.Result, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (c *Client) QueryBatch(ctx context.Context, queries []string, options *index.QueryOptions) ([][]index.Result, error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	u, _ := url.JoinPath(c.url, "/"+c.namespace+"/_msearch")
+
+	var body bytes.Buffer
+
+	for _, query := range queries {
+		body.WriteString("{}\n")
+		body.Write(jsonBytes(searchBody(query, options)))
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, &body)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	var result MultiSearchResult
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	results := make([][]index.Result, len(queries))
+
+	for i, r := range result.Responses {
+		if i >= len(results) {
+			break
+		}
+
+		if r.Error != nil {
+			return nil, errors.New(r.Error.Reason)
+		}
+
+		results[i] = convertResults(r.SearchResult)
+	}
+
+	return results, nil
+}
+
+func (c *Client) Stats(ctx context.Context) (*index.Stats, error) {
+	u, _ := url.JoinPath(c.url, "/"+c.namespace+"/_stats/docs,store")
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &index.Stats{}, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	var result StatsResult
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &index.Stats{
+		Documents: result.All.Primaries.Docs.Count,
+
+		Size: result.All.Primaries.Store.SizeInBytes,
+	}, nil
+}
+
+func (c *Client) Capabilities() index.Capabilities {
+	return index.Capabilities{
+		Filters: true,
+		Paging:  true,
+
+		Aliases: true,
+	}
+}
+
+func (c *Client) ensureIndex(ctx context.Context) error {
+	u, _ := url.JoinPath(c.url, "/"+c.namespace)
+
+	req, _ := http.NewRequestWithContext(ctx, "HEAD", u, nil)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	return c.createIndex(ctx, c.namespace)
+}
+
+func (c *Client) createIndex(ctx context.Context, name string) error {
+	u, _ := url.JoinPath(c.url, "/"+name)
+
+	// metadata strings are analyzed with an exact keyword sub-field, while
+	// numbers, booleans and RFC 3339 dates are mapped to their native types
+	body := map[string]any{
+		"mappings": map[string]any{
+			"date_detection": true,
+
+			"dynamic_templates": []map[string]any{
+				{
+					"metadata_strings": map[string]any{
+						"path_match":         "metadata.*",
+						"match_mapping_type": "string",
+
+						"mapping": map[string]any{
+							"type": "text",
+
+							"fields": map[string]any{
+								"keyword": map[string]any{
+									"type":         "keyword",
+									"ignore_above": 1024,
+								},
+							},
+						},
+					},
+				},
+			},
+
+			"properties": map[string]any{
+				"id": map[string]any{
+					"type": "keyword",
+				},
+
+				"metadata": map[string]any{
+					"type": "object",
+				},
+			},
+		},
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "PUT", u, jsonReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return convertError(resp)
+	}
+
+	return nil
+}
+
+func convertID(id string) string {
+	if id == "" {
+		return uuid.NewString()
+	}
+
+	if _, err := uuid.Parse(id); err == nil {
+		return id
+	}
+
+	return uuid.NewMD5(uuid.NameSpaceOID, []byte(id)).String()
+}
+
+func searchBody(query string, options *index.QueryOptions) map[string]any {
+	if options == nil {
+		options = new(index.QueryOptions)
+	}
+
+	match := map[string]any{
+		"multi_match": map[string]any{
+			"query":    query,
+			"fields":   []string{"content", "metadata.*"},
+			"analyzer": "english",
+			"lenient":  true,
+		},
+	}
+
+	if len(options.Filters) > 0 {
+		match = map[string]any{
+			"bool": map[string]any{
+				"must":   match,
+				"filter": convertFilter(options.Filters),
+			},
+		}
+	}
+
+	body := map[string]any{
+		"query": match,
+	}
+
+	if options.Limit != nil {
+		body["size"] = *options.Limit
+	}
+
+	return body
+}
+
+func convertResults(result SearchResult) []index.Result {
 	var results []index.Result
 
 	for _, hit := range result.Hits.Hits {
@@ -205,34 +562,46 @@ func (c *Client) Query(ctx context.Context, query string, options *index.QueryOp
 				Source:  hit.Document.Source,
 				Content: hit.Document.Content,
 
-				Metadata: hit.Document.Metadata,
+				Metadata: index.NormalizeMetadata(hit.Document.Metadata),
 			},
 		})
 	}
 
-	return results, nil
+	return results
 }
 
-func convertID(id string) string {
-	if id == "" {
-		return uuid.NewString()
-	}
+func convertFilter(filter map[string]any) []map[string]any {
+	var conditions []map[string]any
 
-	if _, err := uuid.Parse(id); err == nil {
-		return id
+	for k, v := range filter {
+		field := "metadata." + k
+
+		if _, ok := v.(string); ok {
+			field += ".keyword"
+		}
+
+		conditions = append(conditions, map[string]any{
+			"term": map[string]any{
+				field: index.EncodeValue(v),
+			},
+		})
 	}
 
-	return uuid.NewMD5(uuid.NameSpaceOID, []byte(id)).String()
+	return conditions
 }
 
 func jsonReader(v any) io.Reader {
+	return bytes.NewReader(jsonBytes(v))
+}
+
+func jsonBytes(v any) []byte {
 	b := new(bytes.Buffer)
 
 	enc := json.NewEncoder(b)
 	enc.SetEscapeHTML(false)
 
 	enc.Encode(v)
-	return b
+	return b.Bytes()
 }
 
 func convertError(resp *http.Response) error {
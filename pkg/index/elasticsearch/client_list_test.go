@@ -0,0 +1,72 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+	"github.com/adrianliechti/wingman-index/pkg/to"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPages(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Size int `json:"size"`
+
+			Sort        []string `json:"sort"`
+			SearchAfter []string `json:"search_after"`
+		}
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, []string{"id"}, body.Sort)
+
+		var after string
+
+		if len(body.SearchAfter) > 0 {
+			after = body.SearchAfter[0]
+		}
+
+		var hits []string
+
+		for _, id := range ids {
+			if id > after && len(hits) < body.Size {
+				hits = append(hits, fmt.Sprintf(`{"_source": {"id": %q}}`, id))
+			}
+		}
+
+		fmt.Fprintf(w, `{"hits": {"hits": [%s]}}`, strings.Join(hits, ","))
+	}))
+
+	defer server.Close()
+
+	c, err := New(server.URL, "test")
+	require.NoError(t, err)
+
+	var result []string
+
+	var cursor string
+
+	for {
+		page, err := c.List(context.Background(), &index.ListOptions{Limit: to.Ptr(2), Cursor: cursor})
+		require.NoError(t, err)
+
+		for _, d := range page.Items {
+			result = append(result, d.ID)
+		}
+
+		if cursor = page.Cursor; cursor == "" {
+			break
+		}
+	}
+
+	require.Equal(t, ids, result)
+}
@@ -7,13 +7,28 @@ type Document struct {
 	Source  string `json:"source"`
 	Content string `json:"content"`
 
-	Metadata map[string]string `json:"metadata"`
+	Metadata map[string]any `json:"metadata"`
 }
 
 type SearchResult struct {
 	Hits SearchHits `json:"hits"`
 }
 
+type MultiSearchResult struct {
+	Responses []MultiSearchResponse `json:"responses"`
+}
+
+type MultiSearchResponse struct {
+	SearchResult
+
+	Error *SearchError `json:"error,omitempty"`
+}
+
+type SearchError struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
 type SearchHits struct {
 	Hits []SearchHit `json:"hits"`
 }
@@ -22,3 +37,30 @@ type SearchHit struct {
 	Score    float32  `json:"_score"`
 	Document Document `json:"_source"`
 }
+
+type GetResult struct {
+	Docs []GetDocument `json:"docs"`
+}
+
+type GetDocument struct {
+	Found    bool     `json:"found"`
+	Document Document `json:"_source"`
+}
+
+type StatsResult struct {
+	All struct {
+		Primaries struct {
+			Docs struct {
+				Count int64 `json:"count"`
+			} `json:"docs"`
+
+			Store struct {
+				SizeInBytes int64 `json:"size_in_bytes"`
+			} `json:"store"`
+		} `json:"primaries"`
+	} `json:"_all"`
+}
+
+type AliasesResult struct {
+	Aliases map[string]any `json:"aliases"`
+}
@@ -0,0 +1,126 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+)
+
+func (c *Client) Namespaces(ctx context.Context) ([]index.Namespace, error) {
+	u, _ := url.JoinPath(c.url, "/_alias")
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	var result map[string]AliasesResult
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	var namespaces []index.Namespace
+
+	for _, name := range slices.Sorted(maps.Keys(result)) {
+		// skip system and hidden indices
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		namespaces = append(namespaces, index.Namespace{
+			Name: name,
+
+			Aliases: slices.Sorted(maps.Keys(result[name].Aliases)),
+		})
+	}
+
+	return namespaces, nil
+}
+
+func (c *Client) CreateNamespace(ctx context.Context, name string, options *index.NamespaceOptions) error {
+	return c.createIndex(ctx, name)
+}
+
+func (c *Client) DropNamespace(ctx context.Context, name string) error {
+	u, _ := url.JoinPath(c.url, "/"+name)
+
+	req, _ := http.NewRequestWithContext(ctx, "DELETE", u, nil)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return convertError(resp)
+	}
+
+	return nil
+}
+
+func (c *Client) AliasNamespace(ctx context.Context, alias, namespace string) error {
+	// both actions are applied atomically by elasticsearch
+	body := map[string]any{
+		"actions": []map[string]any{
+			{
+				"remove": map[string]any{
+					"index": "*",
+					"alias": alias,
+
+					"must_exist": false,
+				},
+			},
+			{
+				"add": map[string]any{
+					"index": namespace,
+					"alias": alias,
+				},
+			},
+		},
+	}
+
+	u, _ := url.JoinPath(c.url, "/_aliases")
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, jsonReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return convertError(resp)
+	}
+
+	return nil
+}
+
+func (c *Client) WithNamespace(name string) index.Provider {
+	client := *c
+	client.namespace = name
+
+	return &client
+}
@@ -11,11 +11,53 @@ type Reranker = provider.Reranker
 
 type Provider interface {
 	List(ctx context.Context, options *ListOptions) (*Page[Document], error)
+	Get(ctx context.Context, ids ...string) ([]Document, error)
 
 	Index(ctx context.Context, documents ...Document) error
+
 	Delete(ctx context.Context, ids ...string) error
+	DeleteWhere(ctx context.Context, filter map[string]any) error
 
 	Query(ctx context.Context, query string, options *QueryOptions) ([]Result, error)
+	QueryVector(ctx context.Context, vector []float32, options *QueryOptions) ([]Result, error)
+
+	// QueryBatch runs several queries in one call and returns their results in order
+	QueryBatch(ctx context.Context, queries []string, options *QueryOptions) ([][]Result, error)
+}
+
+type StatsProvider interface {
+	Stats(ctx context.Context) (*Stats, error)
+}
+
+type CapabilitiesProvider interface {
+	Capabilities() Capabilities
+}
+
+// NamespaceManager is implemented by providers that can manage several
+// namespaces (collections, indexes or classes) on the same backend.
+type NamespaceManager interface {
+	Namespaces(ctx context.Context) ([]Namespace, error)
+
+	CreateNamespace(ctx context.Context, name string, options *NamespaceOptions) error
+	DropNamespace(ctx context.Context, name string) error
+
+	// AliasNamespace points alias at namespace, atomically replacing a previous
+	// target. Backends without alias support return errors.ErrUnsupported.
+	AliasNamespace(ctx context.Context, alias, namespace string) error
+
+	// WithNamespace returns a provider operating on the given namespace or alias
+	WithNamespace(name string) Provider
+}
+
+type Namespace struct {
+	Name string
+
+	Aliases []string
+}
+
+type NamespaceOptions struct {
+	// Dimension of the vectors; probed from the embedder if zero
+	Dimension int
 }
 
 type ListOptions struct {
@@ -26,7 +68,8 @@ type ListOptions struct {
 type QueryOptions struct {
 	Limit *int
 
-	Filters map[string]string
+	// Filters match metadata values for equality; list values match if they contain the value
+	Filters map[string]any
 }
 
 type Page[T Document] struct {
@@ -42,7 +85,7 @@ type Document struct {
 	Source  string
 	Content string
 
-	Metadata map[string]string
+	Metadata Metadata
 
 	Embedding []float32
 }
@@ -51,3 +94,28 @@ type Result struct {
 	Document
 	Score float32
 }
+
+// Stats describes the contents of an index. Fields a backend cannot
+// determine are left zero.
+type Stats struct {
+	Documents int64
+
+	// Sources is the number of distinct document sources, ignoring fragments
+	Sources int64
+
+	Model     string
+	Dimension int
+
+	// Size is the storage size in bytes
+	Size int64
+}
+
+type Capabilities struct {
+	Filters bool
+	Hybrid  bool
+	Paging  bool
+	Vectors bool
+
+	// Aliases reports support for atomic namespace aliases
+	Aliases bool
+}
@@ -13,18 +13,27 @@ import (
 	"github.com/google/uuid"
 )
 
-var _ index.Provider = &Provider{}
+var (
+	_ index.Provider             = &Provider{}
+	_ index.StatsProvider        = &Provider{}
+	_ index.CapabilitiesProvider = &Provider{}
+	_ index.NamespaceManager     = &Provider{}
+)
 
 type Provider struct {
 	embedder index.Embedder
 	reranker index.Reranker
 
-	documents map[string]index.Document
+	namespace string
+
+	store *store
 }
 
 func New(options ...Option) (*Provider, error) {
 	p := &Provider{
-		documents: make(map[string]index.Document),
+		namespace: "default",
+
+		store: newStore(),
 	}
 
 	for _, option := range options {
@@ -35,13 +44,22 @@ func New(options ...Option) (*Provider, error) {
 		return nil, errors.New("embedder is required")
 	}
 
+	if p.namespace == "" {
+		return nil, errors.New("namespace is required")
+	}
+
 	return p, nil
 }
 
 func (p *Provider) List(ctx context.Context, options *index.ListOptions) (*index.Page[index.Document], error) {
-	items := make([]index.Document, 0, len(p.documents))
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+
+	documents := p.store.documents(p.namespace)
 
-	for _, d := range p.documents {
+	items := make([]index.Document, 0, len(documents))
+
+	for _, d := range documents {
 		items = append(items, d)
 	}
 
@@ -52,12 +70,33 @@ func (p *Provider) List(ctx context.Context, options *index.ListOptions) (*index
 	return &page, nil
 }
 
-func (p *Provider) Index(ctx context.Context, documents ...index.Document) error {
-	for _, d := range documents {
-		if d.ID == "" {
-			d.ID = uuid.NewString()
+func (p *Provider) Get(ctx context.Context, ids ...string) ([]index.Document, error) {
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+
+	documents := p.store.documents(p.namespace)
+
+	items := make([]index.Document, 0, len(ids))
+
+	for _, id := range ids {
+		d, ok := documents[id]
+
+		if !ok {
+			continue
 		}
 
+		items = append(items, d)
+	}
+
+	return items, nil
+}
+
+func (p *Provider) Index(ctx context.Context, documents ...index.Document) error {
+	var model string
+
+	documents = slices.Clone(documents)
+
+	for i, d := range documents {
 		if len(d.Embedding) == 0 && p.embedder != nil {
 			embedding, err := p.embedder.Embed(ctx, []string{d.Content})
 
@@ -65,32 +104,77 @@ func (p *Provider) Index(ctx context.Context, documents ...index.Document) error
 				return err
 			}
 
-			d.Embedding = embedding.Embeddings[0]
+			documents[i].Embedding = embedding.Embeddings[0]
+
+			if embedding.Model != "" {
+				model = embedding.Model
+			}
+		}
+	}
+
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	name := p.store.resolve(p.namespace)
+
+	if p.store.namespaces[name] == nil {
+		p.store.namespaces[name] = make(map[string]index.Document)
+	}
+
+	if model != "" {
+		p.store.models[name] = model
+	}
+
+	for _, d := range documents {
+		if d.ID == "" {
+			d.ID = uuid.NewString()
 		}
 
 		if len(d.Embedding) == 0 {
 			continue
 		}
 
-		p.documents[d.ID] = d
+		p.store.namespaces[name][d.ID] = d
 	}
 
 	return nil
 }
 
 func (p *Provider) Delete(ctx context.Context, ids ...string) error {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	documents := p.store.documents(p.namespace)
+
 	for _, id := range ids {
-		delete(p.documents, id)
+		delete(documents, id)
 	}
 
 	return nil
 }
 
-func (p *Provider) Query(ctx context.Context, query string, options *index.QueryOptions) ([]index.Result, error) {
-	if options == nil {
-		options = &index.QueryOptions{}
+func (p *Provider) DeleteWhere(ctx context.Context, filter map[string]any) error {
+	if len(filter) == 0 {
+		return errors.New("filter is required")
+	}
+
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	documents := p.store.documents(p.namespace)
+
+	for id, d := range documents {
+		if !matchFilters(d, filter) {
+			continue
+		}
+
+		delete(documents, id)
 	}
 
+	return nil
+}
+
+func (p *Provider) Query(ctx context.Context, query string, options *index.QueryOptions) ([]index.Result, error) {
 	if p.embedder == nil {
 		return nil, errors.New("no embedder configured")
 	}
@@ -101,23 +185,29 @@ func (p *Provider) Query(ctx context.Context, query string, options *index.Query
 		return nil, err
 	}
 
-	results := make([]index.Result, 0)
+	return p.QueryVector(ctx, embedding.Embeddings[0], options)
+}
 
-DOCUMENTS:
-	for _, d := range p.documents {
-		for k, v := range options.Filters {
-			val, ok := d.Metadata[k]
+func (p *Provider) QueryVector(ctx context.Context, vector []float32, options *index.QueryOptions) ([]index.Result, error) {
+	if options == nil {
+		options = &index.QueryOptions{}
+	}
 
-			if !ok {
-				continue DOCUMENTS
-			}
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
 
-			if !strings.EqualFold(v, val) {
-				continue DOCUMENTS
-			}
+	results := make([]index.Result, 0)
+
+	for _, d := range p.store.documents(p.namespace) {
+		if !matchFilters(d, options.Filters) {
+			continue
+		}
+
+		if len(d.Embedding) != len(vector) {
+			continue
 		}
 
-		score := cosineSimilarity(embedding.Embeddings[0], d.Embedding)
+		score := cosineSimilarity(vector, d.Embedding)
 
 		r := index.Result{
 			Score:    score,
@@ -139,6 +229,102 @@ DOCUMENTS:
 	return results, nil
 }
 
+func (p *Provider) QueryBatch(ctx context.Context, queries []string, options *index.QueryOptions) ([][]index.Result, error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	if p.embedder == nil {
+		return nil, errors.New("no embedder configured")
+	}
+
+	embedding, err := p.embedder.Embed(ctx, queries)
+
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]index.Result, len(queries))
+
+	for i, vector := range embedding.Embeddings {
+		r, err := p.QueryVector(ctx, vector, options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		results[i] = r
+	}
+
+	return results, nil
+}
+
+func (p *Provider) Stats(ctx context.Context) (*index.Stats, error) {
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+
+	documents := p.store.documents(p.namespace)
+
+	sources := map[string]bool{}
+
+	stats := &index.Stats{
+		Documents: int64(len(documents)),
+
+		Model: p.store.models[p.store.resolve(p.namespace)],
+	}
+
+	for _, d := range documents {
+		source, _, _ := strings.Cut(d.Source, "#")
+
+		if source != "" {
+			sources[source] = true
+		}
+
+		if stats.Dimension == 0 {
+			stats.Dimension = len(d.Embedding)
+		}
+	}
+
+	stats.Sources = int64(len(sources))
+
+	return stats, nil
+}
+
+func (p *Provider) Capabilities() index.Capabilities {
+	return index.Capabilities{
+		Filters: true,
+		Vectors: true,
+
+		Aliases: true,
+	}
+}
+
+func matchFilters(d index.Document, filters map[string]any) bool {
+	for k, v := range filters {
+		val, ok := d.Metadata[k]
+
+		if !ok {
+			return false
+		}
+
+		if !matchValue(val, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchValue(val, filter any) bool {
+	if values, ok := val.([]string); ok {
+		return slices.ContainsFunc(values, func(v string) bool {
+			return matchValue(v, filter)
+		})
+	}
+
+	return strings.EqualFold(index.FormatValue(val), index.FormatValue(filter))
+}
+
 func cosineSimilarity(vals1, vals2 []float32) float32 {
 	l2norm := func(v float64, s, t float64) (float64, float64) {
 		if v == 0 {
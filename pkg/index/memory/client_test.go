@@ -3,7 +3,9 @@ package memory_test
 import (
 	"testing"
 
+	"github.com/adrianliechti/wingman-index/pkg/index"
 	"github.com/adrianliechti/wingman-index/pkg/index/memory"
+	"github.com/adrianliechti/wingman-index/pkg/to"
 	"github.com/adrianliechti/wingman-index/test"
 
 	"github.com/stretchr/testify/require"
@@ -17,3 +19,113 @@ func TestMemory(t *testing.T) {
 
 	test.TestIndex(t, context, c)
 }
+
+func TestMemoryGetAndDeleteWhere(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	err = c.Index(context.Context,
+		index.Document{ID: "1", Content: "first chunk of a", Metadata: index.Metadata{"filepath": "/a.md"}},
+		index.Document{ID: "2", Content: "second chunk of a", Metadata: index.Metadata{"filepath": "/a.md"}},
+		index.Document{ID: "3", Content: "first chunk of b", Metadata: index.Metadata{"filepath": "/b.md"}},
+	)
+	require.NoError(t, err)
+
+	docs, err := c.Get(context.Context, "1", "3", "missing")
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	err = c.DeleteWhere(context.Context, map[string]any{"filepath": "/a.md"})
+	require.NoError(t, err)
+
+	docs, err = c.Get(context.Context, "1", "2", "3")
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	require.Equal(t, "3", docs[0].ID)
+
+	require.Error(t, c.DeleteWhere(context.Context, nil))
+}
+
+func TestMemoryStats(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	err = c.Index(context.Context,
+		index.Document{ID: "1", Source: "/a.md#1", Content: "first chunk of a"},
+		index.Document{ID: "2", Source: "/a.md#2", Content: "second chunk of a"},
+		index.Document{ID: "3", Source: "/b.md#1", Content: "first chunk of b"},
+	)
+	require.NoError(t, err)
+
+	stats, err := c.Stats(context.Context)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(3), stats.Documents)
+	require.Equal(t, int64(2), stats.Sources)
+	require.Equal(t, 10, stats.Dimension)
+}
+
+func TestMemoryQueryVectorAndBatch(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	err = c.Index(context.Context,
+		index.Document{ID: "short", Content: "tiny"},
+		index.Document{ID: "long", Content: "a considerably longer text"},
+	)
+	require.NoError(t, err)
+
+	docs, err := c.Get(context.Context, "long")
+	require.NoError(t, err)
+
+	results, err := c.QueryVector(context.Context, docs[0].Embedding, &index.QueryOptions{Limit: to.Ptr(1)})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "long", results[0].ID)
+
+	batch, err := c.QueryBatch(context.Context, []string{"abcd", "another considerably longer text"}, &index.QueryOptions{Limit: to.Ptr(1)})
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+	require.Equal(t, "short", batch[0][0].ID)
+	require.Equal(t, "long", batch[1][0].ID)
+}
+
+func TestMemoryNamespaces(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	require.NoError(t, c.CreateNamespace(context.Context, "blue", nil))
+	require.NoError(t, c.CreateNamespace(context.Context, "green", nil))
+
+	require.NoError(t, c.WithNamespace("blue").Index(context.Context, index.Document{ID: "1", Content: "blue document"}))
+	require.NoError(t, c.WithNamespace("green").Index(context.Context, index.Document{ID: "2", Content: "green document"}))
+
+	live := c.WithNamespace("live")
+
+	require.NoError(t, c.AliasNamespace(context.Context, "live", "blue"))
+
+	docs, err := live.Get(context.Context, "1", "2")
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	require.Equal(t, "1", docs[0].ID)
+
+	require.NoError(t, c.AliasNamespace(context.Context, "live", "green"))
+	require.NoError(t, c.DropNamespace(context.Context, "blue"))
+
+	docs, err = live.Get(context.Context, "1", "2")
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	require.Equal(t, "2", docs[0].ID)
+
+	namespaces, err := c.Namespaces(context.Context)
+	require.NoError(t, err)
+	require.Equal(t, []index.Namespace{{Name: "green", Aliases: []string{"live"}}}, namespaces)
+}
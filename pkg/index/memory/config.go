@@ -17,3 +17,9 @@ func WithReranker(reranker index.Reranker) Option {
 		p.reranker = reranker
 	}
 }
+
+func WithNamespace(namespace string) Option {
+	return func(p *Provider) {
+		p.namespace = namespace
+	}
+}
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+)
+
+type store struct {
+	mu sync.RWMutex
+
+	models     map[string]string
+	aliases    map[string]string
+	namespaces map[string]map[string]index.Document
+}
+
+func newStore() *store {
+	return &store{
+		models:     make(map[string]string),
+		aliases:    make(map[string]string),
+		namespaces: make(map[string]map[string]index.Document),
+	}
+}
+
+func (s *store) resolve(name string) string {
+	if target, ok := s.aliases[name]; ok {
+		return target
+	}
+
+	return name
+}
+
+func (s *store) documents(name string) map[string]index.Document {
+	return s.namespaces[s.resolve(name)]
+}
+
+func (p *Provider) Namespaces(ctx context.Context) ([]index.Namespace, error) {
+	p.store.mu.RLock()
+	defer p.store.mu.RUnlock()
+
+	var result []index.Namespace
+
+	for _, name := range slices.Sorted(maps.Keys(p.store.namespaces)) {
+		ns := index.Namespace{
+			Name: name,
+		}
+
+		for alias, target := range p.store.aliases {
+			if target == name {
+				ns.Aliases = append(ns.Aliases, alias)
+			}
+		}
+
+		slices.Sort(ns.Aliases)
+
+		result = append(result, ns)
+	}
+
+	return result, nil
+}
+
+func (p *Provider) CreateNamespace(ctx context.Context, name string, options *index.NamespaceOptions) error {
+	if name == "" {
+		return errors.New("namespace is required")
+	}
+
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	if _, ok := p.store.aliases[name]; ok {
+		return errors.New("namespace is an alias: " + name)
+	}
+
+	if _, ok := p.store.namespaces[name]; ok {
+		return errors.New("namespace already exists: " + name)
+	}
+
+	p.store.namespaces[name] = make(map[string]index.Document)
+
+	return nil
+}
+
+func (p *Provider) DropNamespace(ctx context.Context, name string) error {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	delete(p.store.models, name)
+	delete(p.store.namespaces, name)
+
+	for alias, target := range p.store.aliases {
+		if target == name {
+			delete(p.store.aliases, alias)
+		}
+	}
+
+	return nil
+}
+
+func (p *Provider) AliasNamespace(ctx context.Context, alias, namespace string) error {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	if _, ok := p.store.namespaces[alias]; ok {
+		return errors.New("alias conflicts with existing namespace: " + alias)
+	}
+
+	if _, ok := p.store.namespaces[namespace]; !ok {
+		return errors.New("namespace not found: " + namespace)
+	}
+
+	p.store.aliases[alias] = namespace
+
+	return nil
+}
+
+func (p *Provider) WithNamespace(name string) index.Provider {
+	return &Provider{
+		embedder: p.embedder,
+		reranker: p.reranker,
+
+		namespace: name,
+
+		store: p.store,
+	}
+}
@@ -0,0 +1,134 @@
+package index
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata holds typed document attributes. Values are string, bool, int64,
+// float64, time.Time or []string.
+type Metadata map[string]any
+
+func (m Metadata) String(key string) string {
+	return FormatValue(m[key])
+}
+
+// Values returns the metadata ready to be stored as JSON, with times
+// formatted as RFC 3339 strings.
+func (m Metadata) Values() map[string]any {
+	if m == nil {
+		return nil
+	}
+
+	result := make(map[string]any, len(m))
+
+	for k, v := range m {
+		result[k] = EncodeValue(v)
+	}
+
+	return result
+}
+
+// FormatValue renders a metadata value as text, e.g. for backends that only
+// store strings. Times use RFC 3339 and lists are joined by commas.
+func FormatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return ""
+	}
+}
+
+func EncodeValue(v any) any {
+	if t, ok := v.(time.Time); ok {
+		return FormatValue(t)
+	}
+
+	return v
+}
+
+// NormalizeMetadata converts values decoded from JSON or of other Go number
+// types into the supported metadata types. Values keep their type: strings
+// are not parsed and floats are not converted to integers, as the type of a
+// field would otherwise depend on its value.
+func NormalizeMetadata(m map[string]any) Metadata {
+	if m == nil {
+		return nil
+	}
+
+	result := make(Metadata, len(m))
+
+	for k, v := range m {
+		if v = NormalizeValue(v); v != nil {
+			result[k] = v
+		}
+	}
+
+	return result
+}
+
+func NormalizeValue(v any) any {
+	switch v := v.(type) {
+	case string, bool, int64, float64, time.Time, []string:
+		return v
+
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint32:
+		return int64(v)
+
+	case float32:
+		return float64(v)
+
+	// numbers decoded with json.Decoder.UseNumber are integers if written so
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+
+		return nil
+
+	case []any:
+		values := make([]string, 0, len(v))
+
+		for _, item := range v {
+			s, ok := item.(string)
+
+			if !ok {
+				return nil
+			}
+
+			values = append(values, s)
+		}
+
+		return values
+
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,65 @@
+package index_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataRoundTrip(t *testing.T) {
+	modified := time.Date(2025, 8, 1, 12, 30, 0, 0, time.UTC)
+
+	metadata := index.Metadata{
+		"filepath": "/docs/readme.md",
+		"size":     int64(1024),
+		"ratio":    0.5,
+		"draft":    true,
+		"modified": modified,
+		"tags":     []string{"a", "b"},
+	}
+
+	data, err := json.Marshal(metadata.Values())
+	require.NoError(t, err)
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var values map[string]any
+	require.NoError(t, dec.Decode(&values))
+
+	result := index.NormalizeMetadata(values)
+
+	require.Equal(t, "/docs/readme.md", result["filepath"])
+	require.Equal(t, int64(1024), result["size"])
+	require.Equal(t, 0.5, result["ratio"])
+	require.Equal(t, true, result["draft"])
+	require.Equal(t, "2025-08-01T12:30:00Z", result["modified"])
+	require.Equal(t, []string{"a", "b"}, result["tags"])
+}
+
+func TestNormalizeValue(t *testing.T) {
+	// strings are kept, even if they look like times
+	require.Equal(t, "2025-08-01T12:30:00Z", index.NormalizeValue("2025-08-01T12:30:00Z"))
+
+	// floats stay floats, even if integral
+	require.Equal(t, 2.0, index.NormalizeValue(2.0))
+	require.Equal(t, 2.0, index.NormalizeValue(float32(2)))
+
+	require.Equal(t, int64(2), index.NormalizeValue(2))
+	require.Equal(t, int64(2), index.NormalizeValue(json.Number("2")))
+	require.Equal(t, 2.5, index.NormalizeValue(json.Number("2.5")))
+}
+
+func TestFormatValue(t *testing.T) {
+	require.Equal(t, "", index.FormatValue(nil))
+	require.Equal(t, "42", index.FormatValue(int64(42)))
+	require.Equal(t, "1.25", index.FormatValue(1.25))
+	require.Equal(t, "false", index.FormatValue(false))
+	require.Equal(t, "a,b", index.FormatValue([]string{"a", "b"}))
+	require.Equal(t, "2025-08-01T12:30:00Z", index.FormatValue(time.Date(2025, 8, 1, 12, 30, 0, 0, time.UTC)))
+}
@@ -8,14 +8,19 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/adrianliechti/wingman-index/pkg/index"
-	"github.com/adrianliechti/wingman-index/pkg/to"
 
 	"github.com/google/uuid"
 )
 
-var _ index.Provider = &Client{}
+var (
+	_ index.Provider             = &Client{}
+	_ index.StatsProvider        = &Client{}
+	_ index.CapabilitiesProvider = &Client{}
+	_ index.NamespaceManager     = &Client{}
+)
 
 type Client struct {
 	client *http.Client
@@ -62,7 +67,7 @@ func (c *Client) List(ctx context.Context, options *index.ListOptions) (*index.P
 	}
 
 	body := map[string]any{
-		"with_vector":  true,
+		"with_vector":  false,
 		"with_payload": true,
 	}
 
@@ -103,9 +108,7 @@ func (c *Client) List(ctx context.Context, options *index.ListOptions) (*index.P
 			Source:  p.Payload.Source,
 			Content: p.Payload.Content,
 
-			Metadata: p.Payload.Metadata,
-
-			Embedding: p.Vector,
+			Metadata: index.NormalizeMetadata(p.Payload.Metadata),
 		})
 	}
 
@@ -115,6 +118,81 @@ func (c *Client) List(ctx context.Context, options *index.ListOptions) (*index.P
 	}, nil
 }
 
+func (c *Client) Get(ctx context.Context, ids ...string) ([]index.Document, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if err := c.ensureCollection(ctx, c.namespace); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]string{}
+
+	var points []string
+
+	for _, id := range ids {
+		key := convertID(id)
+
+		keys[key] = id
+		points = append(points, key)
+	}
+
+	u, _ := url.JoinPath(c.url, "collections/"+c.namespace+"/points")
+
+	body := map[string]any{
+		"ids": points,
+
+		"with_vector":  true,
+		"with_payload": true,
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, jsonReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	var result pointsResult
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	var items []index.Document
+
+	for _, p := range result.Result {
+		id := p.ID
+
+		if key, ok := keys[p.ID]; ok {
+			id = key
+		}
+
+		items = append(items, index.Document{
+			ID: id,
+
+			Title:   p.Payload.Title,
+			Source:  p.Payload.Source,
+			Content: p.Payload.Content,
+
+			Metadata: index.NormalizeMetadata(p.Payload.Metadata),
+
+			Embedding: p.Vector,
+		})
+	}
+
+	return items, nil
+}
+
 func (c *Client) Index(ctx context.Context, documents ...index.Document) error {
 	if len(documents) == 0 {
 		return nil
@@ -152,7 +230,7 @@ func (c *Client) Index(ctx context.Context, documents ...index.Document) error {
 				Source:  d.Source,
 				Content: d.Content,
 
-				Metadata: d.Metadata,
+				Metadata: d.Metadata.Values(),
 			}})
 
 	}
@@ -211,33 +289,107 @@ func (c *Client) Delete(ctx context.Context, ids ...string) error {
 	return nil
 }
 
+func (c *Client) DeleteWhere(ctx context.Context, filter map[string]any) error {
+	if len(filter) == 0 {
+		return errors.New("filter is required")
+	}
+
+	if err := c.ensureCollection(ctx, c.namespace); err != nil {
+		return err
+	}
+
+	u, _ := url.JoinPath(c.url, "collections/"+c.namespace+"/points/delete")
+
+	body := map[string]any{
+		"filter": convertFilter(filter),
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", u+"?wait=true", jsonReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return convertError(resp)
+	}
+
+	return nil
+}
+
 func (c *Client) Query(ctx context.Context, query string, options *index.QueryOptions) ([]index.Result, error) {
-	if options == nil {
-		options = new(index.QueryOptions)
+	embedding, err := c.embedder.Embed(ctx, []string{query})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.QueryVector(ctx, embedding.Embeddings[0], options)
+}
+
+func (c *Client) QueryVector(ctx context.Context, vector []float32, options *index.QueryOptions) ([]index.Result, error) {
+	if err := c.ensureCollection(ctx, c.namespace); err != nil {
+		return nil, err
 	}
 
-	if options.Limit == nil {
-		options.Limit = to.Ptr(10)
+	u, _ := url.JoinPath(c.url, "collections/"+c.namespace+"/points/search")
+
+	body := searchRequest(vector, options)
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, jsonReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	var result queryResult
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return convertResults(result.Result), nil
+}
+
+func (c *Client) QueryBatch(ctx context.Context, queries []string, options *index.QueryOptions) ([][]index.Result, error) {
+	if len(queries) == 0 {
+		return nil, nil
 	}
 
 	if err := c.ensureCollection(ctx, c.namespace); err != nil {
 		return nil, err
 	}
 
-	embedding, err := c.embedder.Embed(ctx, []string{query})
+	embedding, err := c.embedder.Embed(ctx, queries)
 
 	if err != nil {
 		return nil, err
 	}
 
-	u, _ := url.JoinPath(c.url, "collections/"+c.namespace+"/points/search")
+	var searches []map[string]any
 
-	body := map[string]any{
-		"vector": embedding.Embeddings[0],
-		"limit":  options.Limit,
+	for _, vector := range embedding.Embeddings {
+		searches = append(searches, searchRequest(vector, options))
+	}
 
-		"with_vector":  true,
-		"with_payload": true,
+	u, _ := url.JoinPath(c.url, "collections/"+c.namespace+"/points/search/batch")
+
+	body := map[string]any{
+		"searches": searches,
 	}
 
 	req, _ := http.NewRequestWithContext(ctx, "POST", u, jsonReader(body))
@@ -255,33 +407,67 @@ func (c *Client) Query(ctx context.Context, query string, options *index.QueryOp
 		return nil, convertError(resp)
 	}
 
-	var result queryResult
+	var result batchQueryResult
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, err
 	}
 
-	var results []index.Result
+	results := make([][]index.Result, len(queries))
 
-	for _, r := range result.Result {
-		results = append(results, index.Result{
-			Score: r.Score,
+	for i, r := range result.Result {
+		if i >= len(results) {
+			break
+		}
 
-			Document: index.Document{
-				ID: r.ID,
+		results[i] = convertResults(r)
+	}
 
-				Title:   r.Payload.Title,
-				Source:  r.Payload.Source,
-				Content: r.Payload.Content,
+	return results, nil
+}
 
-				Metadata: r.Payload.Metadata,
+func (c *Client) Stats(ctx context.Context) (*index.Stats, error) {
+	u, _ := url.JoinPath(c.url, "/collections/"+c.namespace)
 
-				Embedding: r.Vector,
-			},
-		})
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
 	}
 
-	return results, nil
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &index.Stats{}, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	var result collectionResult
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &index.Stats{
+		Documents: result.Result.PointsCount,
+
+		Dimension: result.Result.Config.Params.Vectors.Size,
+	}, nil
+}
+
+func (c *Client) Capabilities() index.Capabilities {
+	return index.Capabilities{
+		Filters: true,
+		Paging:  true,
+		Vectors: true,
+
+		Aliases: true,
+	}
 }
 
 func (c *Client) ensureCollection(ctx context.Context, name string) error {
@@ -293,34 +479,52 @@ func (c *Client) ensureCollection(ctx context.Context, name string) error {
 		return err
 	}
 
+	defer resp.Body.Close()
+
 	if resp.StatusCode == http.StatusNotFound {
-		embeddings, err := c.embedder.Embed(context.Background(), []string{"init"})
+		return c.createCollection(ctx, name, 0)
+	}
+
+	if resp.StatusCode != 200 {
+		return errors.New("unable to ensure collection")
+	}
+
+	return nil
+}
+
+func (c *Client) createCollection(ctx context.Context, name string, dimension int) error {
+	if dimension == 0 {
+		embeddings, err := c.embedder.Embed(ctx, []string{"init"})
 
 		if err != nil {
 			return err
 		}
 
-		body := map[string]any{
-			"vectors": map[string]any{
-				"size":     len(embeddings.Embeddings[0]),
-				"distance": "Cosine",
-			},
-		}
+		dimension = len(embeddings.Embeddings[0])
+	}
 
-		req, _ := http.NewRequestWithContext(ctx, "PUT", u, jsonReader(body))
-		req.Header.Set("Content-Type", "application/json")
+	u, _ := url.JoinPath(c.url, "/collections/"+name)
 
-		resp, err = c.client.Do(req)
+	body := map[string]any{
+		"vectors": map[string]any{
+			"size":     dimension,
+			"distance": "Cosine",
+		},
+	}
 
-		if err != nil {
-			return err
-		}
+	req, _ := http.NewRequestWithContext(ctx, "PUT", u, jsonReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
 	}
 
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return errors.New("unable to ensure collection")
+	if resp.StatusCode != http.StatusOK {
+		return convertError(resp)
 	}
 
 	return nil
@@ -338,11 +542,97 @@ func convertID(id string) string {
 	return uuid.NewMD5(uuid.NameSpaceOID, []byte(id)).String()
 }
 
+func searchRequest(vector []float32, options *index.QueryOptions) map[string]any {
+	if options == nil {
+		options = new(index.QueryOptions)
+	}
+
+	limit := 10
+
+	if options.Limit != nil {
+		limit = *options.Limit
+	}
+
+	body := map[string]any{
+		"vector": vector,
+		"limit":  limit,
+
+		"with_vector":  true,
+		"with_payload": true,
+	}
+
+	if len(options.Filters) > 0 {
+		body["filter"] = convertFilter(options.Filters)
+	}
+
+	return body
+}
+
+func convertResults(result []result) []index.Result {
+	var results []index.Result
+
+	for _, r := range result {
+		results = append(results, index.Result{
+			Score: r.Score,
+
+			Document: index.Document{
+				ID: r.ID,
+
+				Title:   r.Payload.Title,
+				Source:  r.Payload.Source,
+				Content: r.Payload.Content,
+
+				Metadata: index.NormalizeMetadata(r.Payload.Metadata),
+
+				Embedding: r.Vector,
+			},
+		})
+	}
+
+	return results
+}
+
+func convertFilter(filter map[string]any) map[string]any {
+	var conditions []map[string]any
+
+	for k, v := range filter {
+		condition := map[string]any{
+			"key": "metadata." + k,
+		}
+
+		switch v := v.(type) {
+		case float32, float64, time.Time:
+			condition["range"] = map[string]any{
+				"gte": index.EncodeValue(v),
+				"lte": index.EncodeValue(v),
+			}
+
+		default:
+			condition["match"] = map[string]any{
+				"value": v,
+			}
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	return map[string]any{
+		"must": conditions,
+	}
+}
+
 func convertError(resp *http.Response) error {
-	data, _ := io.ReadAll(resp.Body)
-	text := string(data)
+	type resultType struct {
+		Status struct {
+			Error string `json:"error"`
+		} `json:"status"`
+	}
 
-	println(text)
+	var result resultType
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err == nil && result.Status.Error != "" {
+		return errors.New(result.Status.Error)
+	}
 
 	return errors.New(http.StatusText(resp.StatusCode))
 }
@@ -0,0 +1,22 @@
+package qdrant
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.WriteHeader(http.StatusBadRequest)
+	rec.WriteString(`{"status": {"error": "Wrong input: Vector dimension error"}, "time": 0.001}`)
+
+	require.EqualError(t, convertError(rec.Result()), "Wrong input: Vector dimension error")
+
+	rec = httptest.NewRecorder()
+	rec.WriteHeader(http.StatusBadGateway)
+
+	require.EqualError(t, convertError(rec.Result()), "Bad Gateway")
+}
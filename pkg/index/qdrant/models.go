@@ -5,7 +5,7 @@ type payload struct {
 	Source  string `json:"source,omitempty"`
 	Content string `json:"content,omitempty"`
 
-	Metadata map[string]string `json:"metadata,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
 }
 
 type point struct {
@@ -31,6 +31,14 @@ type queryResult struct {
 	Result []result `json:"result"`
 }
 
+type pointsResult struct {
+	Result []point `json:"result"`
+}
+
+type batchQueryResult struct {
+	Result [][]result `json:"result"`
+}
+
 type scrollResult struct {
 	Result struct {
 		Points []point `json:"points"`
@@ -38,3 +46,39 @@ type scrollResult struct {
 		NextPageOffset string `json:"next_page_offset"`
 	} `json:"result"`
 }
+
+type collectionResult struct {
+	Result struct {
+		Status string `json:"status"`
+
+		PointsCount int64 `json:"points_count"`
+
+		Config struct {
+			Params struct {
+				Vectors struct {
+					Size     int    `json:"size"`
+					Distance string `json:"distance"`
+				} `json:"vectors"`
+			} `json:"params"`
+		} `json:"config"`
+	} `json:"result"`
+}
+
+type collectionsResult struct {
+	Result struct {
+		Collections []struct {
+			Name string `json:"name"`
+		} `json:"collections"`
+	} `json:"result"`
+}
+
+type aliasDescription struct {
+	AliasName      string `json:"alias_name"`
+	CollectionName string `json:"collection_name"`
+}
+
+type aliasesResult struct {
+	Result struct {
+		Aliases []aliasDescription `json:"aliases"`
+	} `json:"result"`
+}
@@ -0,0 +1,147 @@
+package qdrant
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+)
+
+func (c *Client) Namespaces(ctx context.Context) ([]index.Namespace, error) {
+	var collections collectionsResult
+
+	if err := c.getJSON(ctx, "/collections", &collections); err != nil {
+		return nil, err
+	}
+
+	var aliases aliasesResult
+
+	if err := c.getJSON(ctx, "/aliases", &aliases); err != nil {
+		return nil, err
+	}
+
+	var result []index.Namespace
+
+	for _, col := range collections.Result.Collections {
+		ns := index.Namespace{
+			Name: col.Name,
+		}
+
+		for _, a := range aliases.Result.Aliases {
+			if a.CollectionName == col.Name {
+				ns.Aliases = append(ns.Aliases, a.AliasName)
+			}
+		}
+
+		result = append(result, ns)
+	}
+
+	return result, nil
+}
+
+func (c *Client) CreateNamespace(ctx context.Context, name string, options *index.NamespaceOptions) error {
+	if options == nil {
+		options = new(index.NamespaceOptions)
+	}
+
+	return c.createCollection(ctx, name, options.Dimension)
+}
+
+func (c *Client) DropNamespace(ctx context.Context, name string) error {
+	u, _ := url.JoinPath(c.url, "/collections/"+name)
+
+	req, _ := http.NewRequestWithContext(ctx, "DELETE", u, nil)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return convertError(resp)
+	}
+
+	return nil
+}
+
+func (c *Client) AliasNamespace(ctx context.Context, alias, namespace string) error {
+	var aliases aliasesResult
+
+	if err := c.getJSON(ctx, "/aliases", &aliases); err != nil {
+		return err
+	}
+
+	var actions []map[string]any
+
+	// delete and create are applied in one request, so the alias switches atomically
+	if slices.ContainsFunc(aliases.Result.Aliases, func(a aliasDescription) bool { return a.AliasName == alias }) {
+		actions = append(actions, map[string]any{
+			"delete_alias": map[string]any{
+				"alias_name": alias,
+			},
+		})
+	}
+
+	actions = append(actions, map[string]any{
+		"create_alias": map[string]any{
+			"collection_name": namespace,
+			"alias_name":      alias,
+		},
+	})
+
+	body := map[string]any{
+		"actions": actions,
+	}
+
+	u, _ := url.JoinPath(c.url, "/collections/aliases")
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, jsonReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return convertError(resp)
+	}
+
+	return nil
+}
+
+func (c *Client) WithNamespace(name string) index.Provider {
+	client := *c
+	client.namespace = name
+
+	return &client
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, v any) error {
+	u, _ := url.JoinPath(c.url, path)
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return convertError(resp)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
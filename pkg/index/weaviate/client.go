@@ -7,18 +7,22 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"maps"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/adrianliechti/wingman-index/pkg/index"
 
 	"github.com/google/uuid"
 )
 
-var _ index.Provider = &Client{}
+var (
+	_ index.Provider             = &Client{}
+	_ index.StatsProvider        = &Client{}
+	_ index.CapabilitiesProvider = &Client{}
+	_ index.NamespaceManager     = &Client{}
+)
 
 type Client struct {
 	client *http.Client
@@ -67,21 +71,21 @@ func (c *Client) List(ctx context.Context, options *index.ListOptions) (*index.P
 	}
 
 	limit := 25
-	offset := 0
 
 	if options.Limit != nil {
 		limit = *options.Limit
 	}
 
-	if options.Cursor != "" {
-		offset, _ = strconv.Atoi(options.Cursor)
-	}
-
 	query := url.Values{}
 
 	query.Set("class", c.class)
 	query.Set("limit", fmt.Sprintf("%d", limit))
-	query.Set("offset", fmt.Sprintf("%d", offset))
+
+	// the cursor is the ID of the last object; unlike offsets, it is not
+	// limited to the first pages of a class
+	if options.Cursor != "" {
+		query.Set("after", options.Cursor)
+	}
 
 	u, _ := url.JoinPath(c.url, "/v1/objects")
 	u += "?" + query.Encode()
@@ -107,49 +111,41 @@ func (c *Client) List(ctx context.Context, options *index.ListOptions) (*index.P
 	var items []index.Document
 
 	for _, o := range result.Objects {
-		metadata := maps.Clone(o.Properties)
-
-		key := o.Properties["key"]
-		delete(metadata, "key")
+		items = append(items, convertObject(o))
+	}
 
-		title := o.Properties["title"]
-		delete(metadata, "title")
+	var cursor string
 
-		source := o.Properties["source"]
-		delete(metadata, "source")
+	if len(result.Objects) == limit {
+		cursor = result.Objects[len(result.Objects)-1].ID
+	}
 
-		content := o.Properties["content"]
-		delete(metadata, "content")
+	page := index.Page[index.Document]{
+		Items:  items,
+		Cursor: cursor,
+	}
 
-		if key == "" {
-			key = o.ID
-		}
+	return &page, nil
+}
 
-		d := index.Document{
-			ID: key,
+func (c *Client) Get(ctx context.Context, ids ...string) ([]index.Document, error) {
+	var items []index.Document
 
-			Title:   title,
-			Source:  source,
-			Content: content,
+	for _, id := range ids {
+		o, err := c.getObject(ctx, id)
 
-			Metadata: metadata,
+		if err != nil {
+			return nil, err
 		}
 
-		items = append(items, d)
-	}
-
-	cursor := fmt.Sprintf("%d", offset+limit)
-
-	if len(items) < limit {
-		cursor = ""
-	}
+		if o == nil {
+			continue
+		}
 
-	page := index.Page[index.Document]{
-		Items:  items,
-		Cursor: cursor,
+		items = append(items, convertObject(*o))
 	}
 
-	return &page, nil
+	return items, nil
 }
 
 func (c *Client) Index(ctx context.Context, documents ...index.Document) error {
@@ -210,31 +206,113 @@ func (c *Client) Delete(ctx context.Context, ids ...string) error {
 	return result
 }
 
-func (c *Client) Query(ctx context.Context, query string, options *index.QueryOptions) ([]index.Result, error) {
-	var vector strings.Builder
+func (c *Client) DeleteWhere(ctx context.Context, filter map[string]any) error {
+	if len(filter) == 0 {
+		return errors.New("filter is required")
+	}
+
+	var operands []map[string]any
+
+	for _, o := range convertFilter(filter) {
+		operands = append(operands, map[string]any{
+			"path":     []string{o.Path},
+			"operator": o.Operator,
+
+			o.Type: o.Value,
+		})
+	}
+
+	body := map[string]any{
+		"match": map[string]any{
+			"class": c.class,
+
+			"where": map[string]any{
+				"operator": "And",
+				"operands": operands,
+			},
+		},
+	}
+
+	u, _ := url.JoinPath(c.url, "/v1/batch/objects")
+	req, _ := http.NewRequestWithContext(ctx, "DELETE", u, jsonReader(body))
+	req.Header.Set("Content-Type", "application/json")
 
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return convertError(resp)
+	}
+
+	return nil
+}
+
+func (c *Client) Query(ctx context.Context, query string, options *index.QueryOptions) ([]index.Result, error) {
 	embedding, err := c.embedder.Embed(ctx, []string{query})
 
 	if err != nil {
 		return nil, err
 	}
 
-	for i, v := range embedding.Embeddings[0] {
-		if i > 0 {
-			vector.WriteString(", ")
+	return c.search(ctx, query, embedding.Embeddings[0], options)
+}
+
+func (c *Client) QueryVector(ctx context.Context, vector []float32, options *index.QueryOptions) ([]index.Result, error) {
+	return c.search(ctx, "", vector, options)
+}
+
+func (c *Client) QueryBatch(ctx context.Context, queries []string, options *index.QueryOptions) ([][]index.Result, error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	embedding, err := c.embedder.Embed(ctx, queries)
+
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]index.Result, len(queries))
+
+	for i, vector := range embedding.Embeddings {
+		r, err := c.search(ctx, queries[i], vector, options)
+
+		if err != nil {
+			return nil, err
 		}
 
-		vector.WriteString(fmt.Sprintf("%f", v))
+		results[i] = r
+	}
+
+	return results, nil
+}
+
+func (c *Client) search(ctx context.Context, query string, vector []float32, options *index.QueryOptions) ([]index.Result, error) {
+	if options == nil {
+		options = new(index.QueryOptions)
+	}
+
+	properties, err := c.metadataProperties(ctx)
+
+	if err != nil {
+		return nil, err
 	}
 
 	data := executeQueryTemplate(queryData{
 		Class: c.class,
 
 		Query:  query,
-		Vector: embedding.Embeddings[0],
+		Vector: vector,
 
 		Limit: options.Limit,
-		Where: options.Filters,
+		Where: convertFilter(options.Filters),
+
+		Properties: properties,
 	})
 
 	body := map[string]any{
@@ -242,7 +320,10 @@ func (c *Client) Query(ctx context.Context, query string, options *index.QueryOp
 	}
 
 	u, _ := url.JoinPath(c.url, "/v1/graphql")
-	resp, err := c.client.Post(u, "application/json", jsonReader(body))
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, jsonReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
 
 	if err != nil {
 		return nil, err
@@ -256,7 +337,7 @@ func (c *Client) Query(ctx context.Context, query string, options *index.QueryOp
 
 	type responseType struct {
 		Data struct {
-			Get map[string][]document `json:"Get"`
+			Get map[string][]map[string]any `json:"Get"`
 		} `json:"data"`
 
 		Errors []errorDetail `json:"errors"`
@@ -280,27 +361,23 @@ func (c *Client) Query(ctx context.Context, query string, options *index.QueryOp
 
 	results := make([]index.Result, 0)
 
-	for _, d := range result.Data.Get[c.class] {
-		key := d.Additional.ID
+	for _, values := range result.Data.Get[c.class] {
+		var additional additional
 
-		metadata := map[string]string{}
-
-		if d.Key != "" {
-			key = d.Key
+		if data, err := json.Marshal(values["_additional"]); err == nil {
+			json.Unmarshal(data, &additional)
 		}
 
-		r := index.Result{
-			Score: d.Additional.Certainty,
+		delete(values, "_additional")
 
-			Document: index.Document{
-				ID: key,
+		r := index.Result{
+			Score: additional.Certainty,
 
-				Title:   d.Title,
-				Source:  d.Source,
-				Content: d.Content,
+			Document: convertObject(Object{
+				ID: additional.ID,
 
-				Metadata: metadata,
-			},
+				Properties: values,
+			}),
 		}
 
 		results = append(results, r)
@@ -309,6 +386,124 @@ func (c *Client) Query(ctx context.Context, query string, options *index.QueryOp
 	return results, nil
 }
 
+// metadataProperties returns the names of the metadata properties of the
+// class, resolving the class an alias points to
+func (c *Client) metadataProperties(ctx context.Context) ([]string, error) {
+	name := c.class
+
+	var aliases aliasesResult
+
+	if err := c.getJSON(ctx, "/v1/aliases", &aliases); err != nil {
+		return nil, err
+	}
+
+	for _, a := range aliases.Aliases {
+		if strings.EqualFold(a.Alias, c.class) {
+			name = a.Class
+		}
+	}
+
+	var class classResult
+
+	if err := c.getJSON(ctx, "/v1/schema/"+name, &class); err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	for _, p := range class.Properties {
+		if strings.HasPrefix(p.Name, metadataPrefix) {
+			result = append(result, p.Name)
+		}
+	}
+
+	return result, nil
+}
+
+func (c *Client) Stats(ctx context.Context) (*index.Stats, error) {
+	u, _ := url.JoinPath(c.url, "/v1/schema/"+c.class)
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &index.Stats{}, nil
+	}
+
+	body := map[string]any{
+		"query": "{ Aggregate { " + c.class + " { meta { count } } } }",
+	}
+
+	u, _ = url.JoinPath(c.url, "/v1/graphql")
+	req, _ = http.NewRequestWithContext(ctx, "POST", u, jsonReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err = c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	type responseType struct {
+		Data struct {
+			Aggregate map[string][]struct {
+				Meta struct {
+					Count int64 `json:"count"`
+				} `json:"meta"`
+			} `json:"Aggregate"`
+		} `json:"data"`
+
+		Errors []errorDetail `json:"errors"`
+	}
+
+	var result responseType
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Errors) > 0 {
+		var errs []error
+
+		for _, e := range result.Errors {
+			errs = append(errs, errors.New(e.Message))
+		}
+
+		return nil, errors.Join(errs...)
+	}
+
+	stats := &index.Stats{}
+
+	for _, a := range result.Data.Aggregate[c.class] {
+		stats.Documents += a.Meta.Count
+	}
+
+	return stats, nil
+}
+
+func (c *Client) Capabilities() index.Capabilities {
+	return index.Capabilities{
+		Filters: true,
+		Hybrid:  true,
+		Paging:  true,
+		Vectors: true,
+
+		Aliases: true,
+	}
+}
+
 func convertID(id string) string {
 	if id == "" {
 		return uuid.NewString()
@@ -321,18 +516,119 @@ func convertID(id string) string {
 	return uuid.NewMD5(uuid.NameSpaceOID, []byte(id)).String()
 }
 
-func (c *Client) createObject(d index.Document) error {
-	properties := maps.Clone(d.Metadata)
+// metadataPrefix keeps metadata properties apart from the key, title, source
+// and content properties of documents
+const metadataPrefix = "meta_"
 
-	if properties == nil {
-		properties = map[string]string{}
+func convertProperties(d index.Document) map[string]any {
+	properties := map[string]any{
+		"key": d.ID,
+
+		"title":   d.Title,
+		"source":  d.Source,
+		"content": d.Content,
+	}
+
+	for k, v := range d.Metadata.Values() {
+		properties[metadataPrefix+k] = v
+	}
+
+	return properties
+}
+
+func convertObject(o Object) index.Document {
+	values := map[string]any{}
+
+	for k, v := range o.Properties {
+		if name, ok := strings.CutPrefix(k, metadataPrefix); ok {
+			values[name] = v
+		}
+	}
+
+	metadata := index.NormalizeMetadata(values)
+
+	key, _ := o.Properties["key"].(string)
+
+	title, _ := o.Properties["title"].(string)
+	source, _ := o.Properties["source"].(string)
+	content, _ := o.Properties["content"].(string)
+
+	if key == "" {
+		key = o.ID
+	}
+
+	return index.Document{
+		ID: key,
+
+		Title:   title,
+		Source:  source,
+		Content: content,
+
+		Metadata: metadata,
+	}
+}
+
+func (c *Client) getObject(ctx context.Context, id string) (*Object, error) {
+	u, _ := url.JoinPath(c.url, "/v1/objects/"+c.class+"/"+convertID(id))
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, convertError(resp)
+	}
+
+	var o Object
+
+	if err := json.NewDecoder(resp.Body).Decode(&o); err != nil {
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+func convertFilter(filter map[string]any) []whereOperand {
+	var operands []whereOperand
+
+	for k, v := range filter {
+		o := whereOperand{
+			Path:     metadataPrefix + k,
+			Operator: "Equal",
+		}
+
+		switch v := index.NormalizeValue(v).(type) {
+		case bool:
+			o.Type = "valueBoolean"
+			o.Value = v
+		case int64, float64:
+			o.Type = "valueNumber"
+			o.Value = v
+		case time.Time:
+			o.Type = "valueDate"
+			o.Value = index.FormatValue(v)
+		default:
+			o.Type = "valueText"
+			o.Value = index.FormatValue(v)
+		}
+
+		operands = append(operands, o)
 	}
 
-	properties["key"] = d.ID
+	return operands
+}
 
-	properties["title"] = d.Title
-	properties["source"] = d.Source
-	properties["content"] = d.Content
+func (c *Client) createObject(d index.Document) error {
+	properties := convertProperties(d)
 
 	body := map[string]any{
 		"id": convertID(d.ID),
@@ -360,17 +656,7 @@ func (c *Client) createObject(d index.Document) error {
 }
 
 func (c *Client) updateObject(ctx context.Context, d index.Document) error {
-	properties := maps.Clone(d.Metadata)
-
-	if properties == nil {
-		properties = map[string]string{}
-	}
-
-	properties["key"] = d.ID
-
-	properties["title"] = d.Title
-	properties["source"] = d.Source
-	properties["content"] = d.Content
+	properties := convertProperties(d)
 
 	body := map[string]any{
 		"id": convertID(d.ID),
@@ -381,7 +667,7 @@ func (c *Client) updateObject(ctx context.Context, d index.Document) error {
 		"properties": properties,
 	}
 
-	u, _ := url.JoinPath(c.url, "/v1/objects/"+c.class+"/"+d.ID)
+	u, _ := url.JoinPath(c.url, "/v1/objects/"+c.class+"/"+convertID(d.ID))
 	req, err := http.NewRequestWithContext(ctx, "PUT", u, jsonReader(body))
 	req.Header.Set("Content-Type", "application/json")
 
@@ -428,16 +714,6 @@ type errorDetail struct {
 	Message string `json:"message"`
 }
 
-type document struct {
-	Key string `json:"key"`
-
-	Title   string `json:"title,omitempty"`
-	Source  string `json:"source,omitempty"`
-	Content string `json:"content"`
-
-	Additional additional `json:"_additional"`
-}
-
 type additional struct {
 	ID        string  `json:"id"`
 	Distance  float32 `json:"distance"`
@@ -0,0 +1,156 @@
+package weaviate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+	"github.com/adrianliechti/wingman-index/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataProperties(t *testing.T) {
+	d := index.Document{
+		ID: "doc-1",
+
+		Title:   "Guide",
+		Source:  "file:///docs/guide.md",
+		Content: "Hello world.",
+
+		Metadata: index.Metadata{
+			"source":   "docs",
+			"filepath": "/guide.md",
+
+			"key":   "guide.md",
+			"title": "Front Matter Title",
+		},
+	}
+
+	properties := convertProperties(d)
+
+	require.Equal(t, "file:///docs/guide.md", properties["source"])
+	require.Equal(t, "docs", properties["meta_source"])
+	require.Equal(t, "Front Matter Title", properties["meta_title"])
+
+	result := convertObject(Object{ID: convertID(d.ID), Properties: properties})
+
+	require.Equal(t, d.ID, result.ID)
+	require.Equal(t, d.Title, result.Title)
+	require.Equal(t, d.Source, result.Source)
+	require.Equal(t, d.Metadata, result.Metadata)
+}
+
+func TestDeleteWhereMetadata(t *testing.T) {
+	var body struct {
+		Match struct {
+			Class string `json:"class"`
+
+			Where struct {
+				Operator string `json:"operator"`
+
+				Operands []struct {
+					Path     []string `json:"path"`
+					Operator string   `json:"operator"`
+
+					ValueText string `json:"valueText"`
+				} `json:"operands"`
+			} `json:"where"`
+		} `json:"match"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "DELETE", r.Method)
+		require.Equal(t, "/v1/batch/objects", r.URL.Path)
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		w.Write([]byte("{}"))
+	}))
+
+	defer server.Close()
+
+	c, err := New(server.URL, "Test", WithEmbedder(test.NewMockEmbedder()))
+	require.NoError(t, err)
+
+	err = c.DeleteWhere(context.Background(), map[string]any{
+		"source":   "docs",
+		"filepath": "/guide.md",
+	})
+
+	require.NoError(t, err)
+
+	require.Equal(t, "Test", body.Match.Class)
+	require.Equal(t, "And", body.Match.Where.Operator)
+
+	values := map[string]string{}
+
+	for _, o := range body.Match.Where.Operands {
+		require.Len(t, o.Path, 1)
+		require.Equal(t, "Equal", o.Operator)
+
+		values[o.Path[0]] = o.ValueText
+	}
+
+	// the operands address the properties the metadata is written to
+	properties := convertProperties(index.Document{
+		Source: "file:///docs/guide.md",
+
+		Metadata: index.Metadata{
+			"source":   "docs",
+			"filepath": "/guide.md",
+		},
+	})
+
+	require.Len(t, values, 2)
+
+	for path, value := range values {
+		require.Equal(t, properties[path], value)
+	}
+}
+
+func TestQueryMetadata(t *testing.T) {
+	var query string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/aliases":
+			w.Write([]byte(`{"aliases": [{"alias": "Test", "class": "Test_1"}]}`))
+
+		case "/v1/schema/Test_1":
+			w.Write([]byte(`{"class": "Test_1", "properties": [{"name": "key"}, {"name": "content"}, {"name": "meta_language"}]}`))
+
+		case "/v1/graphql":
+			var body struct {
+				Query string `json:"query"`
+			}
+
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			query = body.Query
+
+			w.Write([]byte(`{"data": {"Get": {"Test": [{"key": "doc-1", "content": "Hello", "meta_language": "go", "_additional": {"id": "6f1c3e2a-0000-0000-0000-000000000000", "certainty": 0.5}}]}}}`))
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	defer server.Close()
+
+	c, err := New(server.URL, "Test", WithEmbedder(test.NewMockEmbedder()))
+	require.NoError(t, err)
+
+	results, err := c.QueryVector(context.Background(), []float32{1, 0}, nil)
+	require.NoError(t, err)
+
+	require.Contains(t, query, "meta_language")
+
+	require.Len(t, results, 1)
+	require.Equal(t, "doc-1", results[0].ID)
+	require.Equal(t, "Hello", results[0].Content)
+	require.Equal(t, float32(0.5), results[0].Score)
+	require.Equal(t, index.Metadata{"language": "go"}, results[0].Metadata)
+}
@@ -6,5 +6,26 @@ type Object struct {
 	Created int64 `json:"creationTimeUnix"`
 	Updated int64 `json:"lastUpdateTimeUnix"`
 
-	Properties map[string]string `json:"properties"`
+	Properties map[string]any `json:"properties"`
+}
+
+type schemaResult struct {
+	Classes []struct {
+		Class string `json:"class"`
+	} `json:"classes"`
+}
+
+type classResult struct {
+	Class string `json:"class"`
+
+	Properties []struct {
+		Name string `json:"name"`
+	} `json:"properties"`
+}
+
+type aliasesResult struct {
+	Aliases []struct {
+		Alias string `json:"alias"`
+		Class string `json:"class"`
+	} `json:"aliases"`
 }
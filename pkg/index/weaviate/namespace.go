@@ -0,0 +1,159 @@
+package weaviate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+)
+
+func (c *Client) Namespaces(ctx context.Context) ([]index.Namespace, error) {
+	var schema schemaResult
+
+	if err := c.getJSON(ctx, "/v1/schema", &schema); err != nil {
+		return nil, err
+	}
+
+	var aliases aliasesResult
+
+	if err := c.getJSON(ctx, "/v1/aliases", &aliases); err != nil {
+		return nil, err
+	}
+
+	var result []index.Namespace
+
+	for _, class := range schema.Classes {
+		ns := index.Namespace{
+			Name: class.Class,
+		}
+
+		for _, a := range aliases.Aliases {
+			if a.Class == class.Class {
+				ns.Aliases = append(ns.Aliases, a.Alias)
+			}
+		}
+
+		result = append(result, ns)
+	}
+
+	return result, nil
+}
+
+func (c *Client) CreateNamespace(ctx context.Context, name string, options *index.NamespaceOptions) error {
+	body := map[string]any{
+		"class":      name,
+		"vectorizer": "none",
+	}
+
+	u, _ := url.JoinPath(c.url, "/v1/schema")
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, jsonReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return convertError(resp)
+	}
+
+	return nil
+}
+
+func (c *Client) DropNamespace(ctx context.Context, name string) error {
+	u, _ := url.JoinPath(c.url, "/v1/schema/"+name)
+
+	req, _ := http.NewRequestWithContext(ctx, "DELETE", u, nil)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return convertError(resp)
+	}
+
+	return nil
+}
+
+func (c *Client) AliasNamespace(ctx context.Context, alias, namespace string) error {
+	u, _ := url.JoinPath(c.url, "/v1/aliases/"+alias)
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+
+	method := "PUT"
+
+	body := map[string]any{
+		"class": namespace,
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		method = "POST"
+
+		u, _ = url.JoinPath(c.url, "/v1/aliases")
+		body["alias"] = alias
+	}
+
+	req, _ = http.NewRequestWithContext(ctx, method, u, jsonReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err = c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return convertError(resp)
+	}
+
+	return nil
+}
+
+func (c *Client) WithNamespace(name string) index.Provider {
+	client := *c
+	client.class = name
+
+	return &client
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, v any) error {
+	u, _ := url.JoinPath(c.url, path)
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return convertError(resp)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -3,6 +3,7 @@ package weaviate
 import (
 	"bytes"
 	_ "embed"
+	"encoding/json"
 	"text/template"
 )
 
@@ -19,7 +20,23 @@ type queryData struct {
 	Vector []float32
 
 	Limit *int
-	Where map[string]string
+	Where []whereOperand
+
+	// Properties lists the metadata properties to return
+	Properties []string
+}
+
+type whereOperand struct {
+	Path     string
+	Operator string
+
+	Type  string
+	Value any
+}
+
+func (o whereOperand) Literal() string {
+	data, _ := json.Marshal(o.Value)
+	return string(data)
 }
 
 func executeQueryTemplate(data queryData) string {
@@ -0,0 +1,143 @@
+package indexer
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// references records the content keys used by an indexing run
+type references struct {
+	mu sync.Mutex
+
+	keys map[string]bool
+
+	// incomplete is set if not all items were seen, so entries missing from
+	// keys may still be in use
+	incomplete bool
+}
+
+func (r *references) add(key string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.keys == nil {
+		r.keys = make(map[string]bool)
+	}
+
+	r.keys[key] = true
+}
+
+func (r *references) fail() {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.incomplete = true
+}
+
+// collect removes content and embeddings that were not referenced by the run
+// and not used within the retention period, as well as cache directories
+// left behind by earlier versions. Other content of the cache directory,
+// such as git clones, is left alone.
+func (idx *Indexer) collect(refs *references) error {
+	if idx.CacheRetention <= 0 {
+		return nil
+	}
+
+	deadline := time.Now().Add(-idx.CacheRetention)
+
+	removed, err := idx.state.collect(refs, deadline)
+
+	if err != nil {
+		return err
+	}
+
+	if !refs.incomplete {
+		for _, entry := range cacheEntries(idx.CacheDir) {
+			if refs.keys[filepath.Base(entry)] {
+				continue
+			}
+
+			info, err := os.Stat(entry)
+
+			if err != nil || info.ModTime().After(deadline) {
+				continue
+			}
+
+			if err := removeEntry(entry); err != nil {
+				return err
+			}
+
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		log.Printf("removed %d unused cache entries", removed)
+	}
+
+	return nil
+}
+
+// removeEntry removes a cache directory and its shard directories once empty
+func removeEntry(entry string) error {
+	if err := os.RemoveAll(entry); err != nil {
+		return err
+	}
+
+	os.Remove(filepath.Dir(entry))
+	os.Remove(filepath.Dir(filepath.Dir(entry)))
+
+	return nil
+}
+
+// cacheEntries returns the cache directories of earlier versions in the
+// xx/yy/<key> layout
+func cacheEntries(dir string) []string {
+	shard := func(name string) bool {
+		return len(name) == 2
+	}
+
+	key := func(name string) bool {
+		return len(name) >= 32
+	}
+
+	var result []string
+
+	for _, a := range hexDirs(dir, shard) {
+		for _, b := range hexDirs(a, shard) {
+			result = append(result, hexDirs(b, key)...)
+		}
+	}
+
+	return result
+}
+
+// hexDirs returns the subdirectories of dir with hex names accepted by match
+func hexDirs(dir string, match func(name string) bool) []string {
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		return nil
+	}
+
+	var result []string
+
+	for _, e := range entries {
+		if e.IsDir() && match(e.Name()) && isHex(e.Name()) {
+			result = append(result, filepath.Join(dir, e.Name()))
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,71 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/adrianliechti/wingman/pkg/client"
+)
+
+// ErrUnsupported is returned by extractors for formats they cannot handle
+var ErrUnsupported = errors.New("unsupported format")
+
+// Extractor converts the content of a file to text
+type Extractor interface {
+	// Extract returns the text of the file name, or ErrUnsupported
+	Extract(ctx context.Context, name string, data []byte) (string, error)
+}
+
+// Extractors tries each extractor in turn until one supports the format
+type Extractors []Extractor
+
+func (e Extractors) Extract(ctx context.Context, name string, data []byte) (string, error) {
+	for _, extractor := range e {
+		text, err := extractor.Extract(ctx, name, data)
+
+		if errors.Is(err, ErrUnsupported) {
+			continue
+		}
+
+		return text, err
+	}
+
+	return "", ErrUnsupported
+}
+
+// NewLocalExtractor returns the built-in extractors for plain text, HTML,
+// JSON and Office Open XML files
+func NewLocalExtractor() Extractor {
+	return Extractors{
+		&textExtractor{},
+		&htmlExtractor{},
+		&officeExtractor{},
+	}
+}
+
+// ClientExtractor extracts text using the wingman extraction API
+type ClientExtractor struct {
+	client *client.Client
+}
+
+func NewClientExtractor(client *client.Client) *ClientExtractor {
+	return &ClientExtractor{
+		client: client,
+	}
+}
+
+func (e *ClientExtractor) Extract(ctx context.Context, name string, data []byte) (string, error) {
+	body := client.ExtractionRequest{
+		Name:   name,
+		Reader: bytes.NewReader(data),
+	}
+
+	content, err := e.client.Extractions.New(ctx, body)
+
+	if err != nil {
+		return "", err
+	}
+
+	return content.Text, nil
+}
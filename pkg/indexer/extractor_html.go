@@ -0,0 +1,175 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// htmlExtractor converts HTML pages to markdown-like text, keeping headings,
+// paragraphs, list items and preformatted blocks apart
+type htmlExtractor struct{}
+
+func (e *htmlExtractor) Extract(ctx context.Context, name string, data []byte) (string, error) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".html", ".htm", ".xhtml":
+	default:
+		return "", ErrUnsupported
+	}
+
+	doc, err := html.Parse(bytes.NewReader(data))
+
+	if err != nil {
+		return "", err
+	}
+
+	w := &textWriter{}
+	renderHTML(w, doc, false)
+
+	return w.String(), nil
+}
+
+var (
+	skippedElements = map[string]bool{
+		"head":     true,
+		"script":   true,
+		"style":    true,
+		"noscript": true,
+		"template": true,
+		"svg":      true,
+		"nav":      true,
+	}
+
+	blockElements = map[string]bool{
+		"p": true, "div": true, "section": true, "article": true, "main": true,
+		"header": true, "footer": true, "aside": true, "blockquote": true,
+		"ul": true, "ol": true, "dl": true, "dt": true, "dd": true,
+		"table": true, "tr": true, "figure": true, "figcaption": true,
+		"hr": true, "br": true, "form": true, "fieldset": true, "address": true,
+	}
+
+	headingLevels = map[string]int{
+		"h1": 1, "h2": 2, "h3": 3, "h4": 4, "h5": 5, "h6": 6,
+	}
+)
+
+func renderHTML(w *textWriter, n *html.Node, pre bool) {
+	switch n.Type {
+	case html.TextNode:
+		if pre {
+			w.WriteRaw(n.Data)
+		} else {
+			w.WriteText(n.Data)
+		}
+
+		return
+
+	case html.ElementNode:
+		if skippedElements[n.Data] {
+			return
+		}
+
+		switch {
+		case headingLevels[n.Data] > 0:
+			w.Block()
+			w.WriteRaw(strings.Repeat("#", headingLevels[n.Data]) + " ")
+
+		case n.Data == "li":
+			w.Line()
+			w.WriteRaw("- ")
+
+		case n.Data == "td" || n.Data == "th":
+			w.WriteRaw("\t")
+
+		case n.Data == "pre":
+			w.Block()
+			pre = true
+
+		case blockElements[n.Data]:
+			w.Block()
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		renderHTML(w, c, pre)
+	}
+
+	if n.Type == html.ElementNode {
+		if headingLevels[n.Data] > 0 || n.Data == "pre" || blockElements[n.Data] {
+			w.Block()
+		}
+	}
+}
+
+// textWriter collapses whitespace of inline text and separates blocks by
+// blank lines
+type textWriter struct {
+	sb strings.Builder
+
+	// space is set if inline whitespace is pending
+	space bool
+}
+
+func (w *textWriter) WriteText(s string) {
+	fields := strings.Fields(s)
+
+	if len(fields) == 0 {
+		w.space = w.space || s != ""
+		return
+	}
+
+	w.space = w.space || isSpace(s[0])
+
+	for i, field := range fields {
+		if (i > 0 || w.space) && !w.atLineStart() {
+			w.sb.WriteString(" ")
+		}
+
+		w.sb.WriteString(field)
+	}
+
+	w.space = isSpace(s[len(s)-1])
+}
+
+func (w *textWriter) WriteRaw(s string) {
+	if w.space && !w.atLineStart() && !strings.HasPrefix(s, "\t") {
+		w.sb.WriteString(" ")
+	}
+
+	w.sb.WriteString(s)
+	w.space = false
+}
+
+// Line starts a new line
+func (w *textWriter) Line() {
+	if !w.atLineStart() {
+		w.sb.WriteString("\n")
+	}
+
+	w.space = false
+}
+
+// Block starts a new paragraph
+func (w *textWriter) Block() {
+	w.Line()
+
+	if s := w.sb.String(); s != "" && !strings.HasSuffix(s, "\n\n") {
+		w.sb.WriteString("\n")
+	}
+}
+
+func (w *textWriter) String() string {
+	return strings.TrimSpace(w.sb.String()) + "\n"
+}
+
+func (w *textWriter) atLineStart() bool {
+	s := w.sb.String()
+	return s == "" || strings.HasSuffix(s, "\n")
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
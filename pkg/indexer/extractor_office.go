@@ -0,0 +1,495 @@
+package indexer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// officeExtractor reads the text of Office Open XML documents, presentations
+// and workbooks
+type officeExtractor struct{}
+
+func (e *officeExtractor) Extract(ctx context.Context, name string, data []byte) (string, error) {
+	var extract func(*zip.Reader) (string, error)
+
+	switch strings.ToLower(path.Ext(name)) {
+	case ".docx":
+		extract = extractDocx
+
+	case ".pptx":
+		extract = extractPptx
+
+	case ".xlsx":
+		extract = extractXlsx
+
+	default:
+		return "", ErrUnsupported
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	if err != nil {
+		return "", err
+	}
+
+	return extract(r)
+}
+
+// extractDocx returns the paragraphs of a document; headings are prefixed
+// with # by their outline level
+func extractDocx(r *zip.Reader) (string, error) {
+	d, err := openXML(r, "word/document.xml")
+
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	var para strings.Builder
+
+	heading := 0
+
+	for {
+		tok, err := d.Token()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				para.Reset()
+				heading = 0
+
+			case "pStyle":
+				heading = headingLevel(xmlAttr(t, "val"))
+
+			case "tab":
+				para.WriteString("\t")
+
+			case "br", "cr":
+				para.WriteString("\n")
+
+			case "t":
+				var text string
+
+				if err := d.DecodeElement(&text, &t); err != nil {
+					return "", err
+				}
+
+				para.WriteString(text)
+			}
+
+		case xml.EndElement:
+			if t.Name.Local != "p" {
+				continue
+			}
+
+			text := strings.TrimSpace(para.String())
+
+			if text == "" {
+				continue
+			}
+
+			if heading > 0 {
+				sb.WriteString(strings.Repeat("#", heading) + " ")
+			}
+
+			sb.WriteString(text)
+			sb.WriteString("\n\n")
+		}
+	}
+
+	return sb.String(), nil
+}
+
+var headingStyle = regexp.MustCompile(`(?i)^heading\s*([1-6])$`)
+
+// headingLevel returns the level of a heading paragraph style, or 0
+func headingLevel(style string) int {
+	if strings.EqualFold(style, "title") {
+		return 1
+	}
+
+	m := headingStyle.FindStringSubmatch(style)
+
+	if m == nil {
+		return 0
+	}
+
+	level, _ := strconv.Atoi(m[1])
+	return level
+}
+
+// extractPptx returns the paragraphs of each slide in order
+func extractPptx(r *zip.Reader) (string, error) {
+	var slides []string
+
+	for _, f := range r.File {
+		if path.Dir(f.Name) == "ppt/slides" && strings.HasPrefix(path.Base(f.Name), "slide") && path.Ext(f.Name) == ".xml" {
+			slides = append(slides, f.Name)
+		}
+	}
+
+	slices.SortFunc(slides, func(a, b string) int {
+		return fileNumber(a) - fileNumber(b)
+	})
+
+	var sb strings.Builder
+
+	for i, name := range slides {
+		paragraphs, err := xmlParagraphs(r, name)
+
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(&sb, "## Slide %d\n\n", i+1)
+
+		for _, p := range paragraphs {
+			sb.WriteString(p)
+			sb.WriteString("\n")
+		}
+
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), nil
+}
+
+// xmlParagraphs returns the text of the a:p paragraphs of a DrawingML part
+func xmlParagraphs(r *zip.Reader, name string) ([]string, error) {
+	d, err := openXML(r, name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	var para strings.Builder
+
+	for {
+		tok, err := d.Token()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				para.Reset()
+
+			case "br":
+				para.WriteString("\n")
+
+			case "t":
+				var text string
+
+				if err := d.DecodeElement(&text, &t); err != nil {
+					return nil, err
+				}
+
+				para.WriteString(text)
+			}
+
+		case xml.EndElement:
+			if t.Name.Local == "p" {
+				if text := strings.TrimSpace(para.String()); text != "" {
+					result = append(result, text)
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// extractXlsx returns the rows of each sheet with tab separated cells
+func extractXlsx(r *zip.Reader) (string, error) {
+	shared, err := sharedStrings(r)
+
+	if err != nil {
+		return "", err
+	}
+
+	sheets, err := workbookSheets(r)
+
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	for _, sheet := range sheets {
+		rows, err := sheetRows(r, sheet.path, shared)
+
+		if err != nil {
+			return "", err
+		}
+
+		if len(rows) == 0 {
+			continue
+		}
+
+		sb.WriteString("# " + sheet.name + "\n\n")
+
+		for _, row := range rows {
+			sb.WriteString(strings.Join(row, "\t"))
+			sb.WriteString("\n")
+		}
+
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), nil
+}
+
+type sheet struct {
+	name string
+	path string
+}
+
+// workbookSheets returns the sheets of a workbook in order
+func workbookSheets(r *zip.Reader) ([]sheet, error) {
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+
+	if err := decodeXML(r, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	if err := decodeXML(r, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+
+	targets := map[string]string{}
+
+	for _, rel := range rels.Relationships {
+		target := rel.Target
+
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+
+		targets[rel.ID] = target
+	}
+
+	var result []sheet
+
+	for _, s := range workbook.Sheets {
+		if target, ok := targets[s.ID]; ok {
+			result = append(result, sheet{name: s.Name, path: target})
+		}
+	}
+
+	return result, nil
+}
+
+// sharedStrings returns the shared string table of a workbook
+func sharedStrings(r *zip.Reader) ([]string, error) {
+	d, err := openXML(r, "xl/sharedStrings.xml")
+
+	if err != nil {
+		// workbooks without text have no shared strings
+		return nil, nil
+	}
+
+	var result []string
+	var item strings.Builder
+
+	for {
+		tok, err := d.Token()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				item.Reset()
+
+			case "t":
+				var text string
+
+				if err := d.DecodeElement(&text, &t); err != nil {
+					return nil, err
+				}
+
+				item.WriteString(text)
+			}
+
+		case xml.EndElement:
+			if t.Name.Local == "si" {
+				result = append(result, item.String())
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// sheetRows returns the non-empty rows of a worksheet
+func sheetRows(r *zip.Reader, name string, shared []string) ([][]string, error) {
+	var worksheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline struct {
+					Text []string `xml:"t"`
+					Runs []struct {
+						Text string `xml:"t"`
+					} `xml:"r"`
+				} `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+
+	if err := decodeXML(r, name, &worksheet); err != nil {
+		return nil, err
+	}
+
+	var result [][]string
+
+	for _, row := range worksheet.Rows {
+		var cells []string
+
+		for _, c := range row.Cells {
+			value := c.Value
+
+			switch c.Type {
+			case "s":
+				if i, err := strconv.Atoi(value); err == nil && i >= 0 && i < len(shared) {
+					value = shared[i]
+				}
+
+			case "inlineStr":
+				value = strings.Join(c.Inline.Text, "")
+
+				for _, run := range c.Inline.Runs {
+					value += run.Text
+				}
+
+			case "b":
+				value = strings.ToUpper(strconv.FormatBool(value == "1"))
+			}
+
+			// keep the column of cells after empty ones
+			if col := columnIndex(c.Ref); col > len(cells) {
+				cells = append(cells, make([]string, col-len(cells))...)
+			}
+
+			cells = append(cells, strings.TrimSpace(value))
+		}
+
+		if strings.TrimSpace(strings.Join(cells, "")) == "" {
+			continue
+		}
+
+		result = append(result, cells)
+	}
+
+	return result, nil
+}
+
+// columnIndex returns the zero based column of a cell reference such as "C7"
+func columnIndex(ref string) int {
+	col := 0
+
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+
+		col = col*26 + int(c-'A'+1)
+	}
+
+	return col - 1
+}
+
+// fileNumber returns the number in a part name such as "ppt/slides/slide12.xml"
+func fileNumber(name string) int {
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	digits := strings.TrimLeft(base, "abcdefghijklmnopqrstuvwxyz")
+
+	n, _ := strconv.Atoi(digits)
+	return n
+}
+
+func openXML(r *zip.Reader, name string) (*xml.Decoder, error) {
+	f, err := r.Open(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(f)
+	f.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return xml.NewDecoder(bytes.NewReader(data)), nil
+}
+
+func decodeXML(r *zip.Reader, name string, v any) error {
+	d, err := openXML(r, name)
+
+	if err != nil {
+		return err
+	}
+
+	return d.Decode(v)
+}
+
+func xmlAttr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+
+	return ""
+}
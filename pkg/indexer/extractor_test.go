@@ -0,0 +1,113 @@
+package indexer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalExtractor(t *testing.T) {
+	ctx := context.Background()
+
+	e := NewLocalExtractor()
+
+	for _, test := range []struct {
+		name string
+		data []byte
+
+		expected string
+	}{
+		{
+			name:     "notes.txt",
+			data:     []byte("\xEF\xBB\xBFline one\r\nline two\r\n"),
+			expected: "line one\nline two\n",
+		},
+		{
+			name:     "table.csv",
+			data:     []byte("name,value\n\"a, b\",1\n"),
+			expected: "name\tvalue\na, b\t1\n",
+		},
+		{
+			name:     "data.json",
+			data:     []byte(`{"name":"a","values":[1,2]}`),
+			expected: "{\n  \"name\": \"a\",\n  \"values\": [\n    1,\n    2\n  ]\n}",
+		},
+		{
+			name: "page.html",
+			data: []byte(`<html><head><title>Ignored</title><style>p{}</style></head><body>
+				<h1>Guide</h1>
+				<p>Some   <b>bold</b>
+				text.</p>
+				<ul><li>one</li><li>two</li></ul>
+				<script>alert(1)</script>
+				<pre>a  b
+c</pre>
+			</body></html>`),
+			expected: "# Guide\n\nSome bold text.\n\n- one\n- two\n\na  b\nc\n",
+		},
+		{
+			name: "document.docx",
+			data: zipFile(t, map[string]string{
+				"word/document.xml": `<w:document xmlns:w="w"><w:body>
+					<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Title</w:t></w:r></w:p>
+					<w:p><w:r><w:t xml:space="preserve">Hello </w:t></w:r><w:r><w:t>World</w:t></w:r></w:p>
+					<w:p></w:p>
+				</w:body></w:document>`,
+			}),
+			expected: "# Title\n\nHello World\n\n",
+		},
+		{
+			name: "slides.pptx",
+			data: zipFile(t, map[string]string{
+				"ppt/slides/slide10.xml": `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>Last</a:t></a:r></a:p></p:sld>`,
+				"ppt/slides/slide2.xml":  `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>First</a:t></a:r></a:p><a:p><a:r><a:t>Point</a:t></a:r></a:p></p:sld>`,
+			}),
+			expected: "## Slide 1\n\nFirst\nPoint\n\n## Slide 2\n\nLast\n\n",
+		},
+		{
+			name: "book.xlsx",
+			data: zipFile(t, map[string]string{
+				"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>
+					<sheet name="Prices" r:id="rId1"/>
+				</sheets></workbook>`,
+				"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+				"xl/sharedStrings.xml":       `<sst><si><t>Item</t></si><si><t>Price</t></si><si><r><t>App</t></r><r><t>le</t></r></si></sst>`,
+				"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+					<row><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+					<row><c r="A2" t="s"><v>2</v></c><c r="C2"><v>1.5</v></c></row>
+					<row><c r="A3" t="inlineStr"><is><t>Pear</t></is></c></row>
+				</sheetData></worksheet>`,
+			}),
+			expected: "# Prices\n\nItem\tPrice\nApple\t\t1.5\nPear\n\n",
+		},
+	} {
+		text, err := e.Extract(ctx, test.name, test.data)
+
+		require.NoError(t, err, test.name)
+		require.Equal(t, test.expected, text, test.name)
+	}
+
+	_, err := e.Extract(ctx, "scan.pdf", []byte("%PDF"))
+	require.ErrorIs(t, err, ErrUnsupported)
+}
+
+func zipFile(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+
+	for name, content := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
@@ -0,0 +1,85 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"path"
+	"strings"
+)
+
+// textExtractor handles plain text, source code, delimited and JSON files
+type textExtractor struct{}
+
+func (e *textExtractor) Extract(ctx context.Context, name string, data []byte) (string, error) {
+	if Language(name) != "" {
+		return normalizeText(data), nil
+	}
+
+	switch strings.ToLower(path.Ext(name)) {
+	case ".txt", ".rst":
+		return normalizeText(data), nil
+
+	case ".md", ".markdown":
+		// front matter is stored as metadata
+		_, _, body, _ := splitFrontMatter(normalizeText(data))
+		return body, nil
+
+	case ".csv":
+		return extractDelimited(data, ',')
+
+	case ".tsv":
+		return extractDelimited(data, '\t')
+
+	case ".json":
+		var buf bytes.Buffer
+
+		if err := json.Indent(&buf, bytes.TrimPrefix(data, bom), "", "  "); err != nil {
+			return "", err
+		}
+
+		return buf.String(), nil
+	}
+
+	return "", ErrUnsupported
+}
+
+var bom = []byte{0xEF, 0xBB, 0xBF}
+
+// normalizeText strips a byte order mark, unifies line endings and replaces
+// invalid UTF-8
+func normalizeText(data []byte) string {
+	text := string(bytes.TrimPrefix(data, bom))
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ToValidUTF8(text, "�")
+
+	return text
+}
+
+// extractDelimited renders the rows of a delimited file with tab separated
+// fields. Malformed files are returned as plain text.
+func extractDelimited(data []byte, comma rune) (string, error) {
+	text := normalizeText(data)
+
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	records, err := r.ReadAll()
+
+	if err != nil {
+		return text, nil
+	}
+
+	var sb strings.Builder
+
+	for _, record := range records {
+		sb.WriteString(strings.Join(record, "\t"))
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,120 @@
+package indexer
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// isMarkdown reports whether the file may start with front matter
+func isMarkdown(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md", ".markdown":
+		return true
+	}
+
+	return false
+}
+
+// splitFrontMatter separates YAML (---) or TOML (+++) front matter from the
+// rest of a markdown file
+func splitFrontMatter(text string) (delimiter, header, body string, ok bool) {
+	first, rest, found := strings.Cut(text, "\n")
+
+	if !found {
+		return "", "", text, false
+	}
+
+	delimiter = strings.TrimRight(first, " \t")
+
+	if delimiter != "---" && delimiter != "+++" {
+		return "", "", text, false
+	}
+
+	offset := 0
+
+	for _, line := range strings.SplitAfter(rest, "\n") {
+		trimmed := strings.TrimRight(line, " \t\n")
+
+		if trimmed == delimiter || (delimiter == "---" && trimmed == "...") {
+			return delimiter, rest[:offset], rest[offset+len(line):], true
+		}
+
+		offset += len(line)
+	}
+
+	return "", "", text, false
+}
+
+// parseFrontMatter returns the fields of the front matter of a markdown file
+// and the text following it
+func parseFrontMatter(text string) (index.Metadata, string, error) {
+	delimiter, header, body, ok := splitFrontMatter(text)
+
+	if !ok {
+		return nil, text, nil
+	}
+
+	fields := map[string]any{}
+
+	switch delimiter {
+	case "---":
+		if err := yaml.Unmarshal([]byte(header), &fields); err != nil {
+			return nil, body, err
+		}
+
+	case "+++":
+		if err := toml.Unmarshal([]byte(header), &fields); err != nil {
+			return nil, body, err
+		}
+	}
+
+	return normalizeFields(fields), body, nil
+}
+
+// dateFields are parsed as dates if given as strings
+var dateFields = map[string]bool{
+	"date":      true,
+	"created":   true,
+	"updated":   true,
+	"lastmod":   true,
+	"published": true,
+}
+
+// normalizeFields converts front matter or sidecar values to metadata. Keys
+// are lower cased; nested objects and lists of other values than strings are
+// dropped.
+func normalizeFields(fields map[string]any) index.Metadata {
+	result := index.Metadata{}
+
+	for k, v := range fields {
+		k = strings.ToLower(strings.TrimSpace(k))
+
+		if s, ok := v.(string); ok && dateFields[k] {
+			if t, ok := parseDate(s); ok {
+				v = t
+			}
+		}
+
+		if v = index.NormalizeValue(v); v != nil {
+			result[k] = v
+		}
+	}
+
+	return result
+}
+
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
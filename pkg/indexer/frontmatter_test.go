@@ -0,0 +1,49 @@
+package indexer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	fields, body, err := parseFrontMatter("---\ntitle: Guide\nTags: [setup, ops]\ndate: 2024-03-01\nowner:\n  team: platform\n---\n# Heading\n")
+	require.NoError(t, err)
+	require.Equal(t, "# Heading\n", body)
+
+	require.Equal(t, index.Metadata{
+		"title": "Guide",
+		"tags":  []string{"setup", "ops"},
+		"date":  time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	}, fields)
+
+	fields, body, err = parseFrontMatter("+++\ntitle = \"Guide\"\ndescription = \"How to start\"\nweight = 3\n+++\nText\n")
+	require.NoError(t, err)
+	require.Equal(t, "Text\n", body)
+
+	require.Equal(t, index.Metadata{
+		"title":       "Guide",
+		"description": "How to start",
+		"weight":      int64(3),
+	}, fields)
+
+	// quoted values stay strings and floats stay floats, as in the source
+	fields, _, err = parseFrontMatter("---\nversion: \"2024-03-01T10:00:00Z\"\nrating: 4.0\n---\nText\n")
+	require.NoError(t, err)
+
+	require.Equal(t, index.Metadata{
+		"version": "2024-03-01T10:00:00Z",
+		"rating":  4.0,
+	}, fields)
+
+	fields, body, err = parseFrontMatter("# Guide\n\n---\n")
+	require.NoError(t, err)
+	require.Nil(t, fields)
+	require.Equal(t, "# Guide\n\n---\n", body)
+
+	_, _, err = parseFrontMatter("---\ntitle: [\n---\nText\n")
+	require.Error(t, err)
+}
@@ -0,0 +1,136 @@
+package git
+
+import (
+	"path"
+	"strings"
+
+	"github.com/adrianliechti/wingman-index/pkg/config"
+	"github.com/adrianliechti/wingman-index/pkg/indexer"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// filter decides which files of a tree are indexed, based on the configured
+// file rules and the .indexignore files committed to the tree
+type filter struct {
+	config.Files
+
+	include *indexer.Patterns
+	exclude *indexer.Patterns
+
+	// ignores holds the parsed .indexignore file of each directory
+	ignores map[string]*indexer.Patterns
+}
+
+func newFilter(tree *object.Tree, files config.Files) (*filter, error) {
+	f := &filter{
+		Files: files,
+
+		include: indexer.CompilePatterns("", files.Include),
+		exclude: indexer.CompilePatterns("", files.Exclude),
+
+		ignores: make(map[string]*indexer.Patterns),
+	}
+
+	err := tree.Files().ForEach(func(file *object.File) error {
+		if path.Base(file.Name) != indexer.IgnoreFile {
+			return nil
+		}
+
+		content, err := file.Contents()
+
+		if err != nil {
+			return err
+		}
+
+		dir := path.Dir(file.Name)
+
+		if dir == "." {
+			dir = ""
+		}
+
+		f.ignores[dir] = indexer.CompilePatterns(dir, strings.Split(content, "\n"))
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// accepted reports whether the file rel (slash separated, relative to the
+// root of the tree) is indexed
+func (f *filter) accepted(rel string, size int64) bool {
+	if !indexer.Supported(rel, f.Extensions) {
+		return false
+	}
+
+	if f.MaxSize > 0 && size > f.MaxSize {
+		return false
+	}
+
+	parts := strings.Split(rel, "/")
+
+	for i := range parts {
+		if f.excluded(strings.Join(parts[:i+1], "/"), i < len(parts)-1) {
+			return false
+		}
+	}
+
+	if !f.include.Empty() && !f.include.MatchAny(rel) {
+		return false
+	}
+
+	return true
+}
+
+// excluded reports whether the file or directory rel is excluded by its name,
+// the exclude patterns or an .indexignore file
+func (f *filter) excluded(rel string, dir bool) bool {
+	name := path.Base(rel)
+
+	if name == indexer.IgnoreFile {
+		return true
+	}
+
+	if !f.Hidden && strings.HasPrefix(name, ".") {
+		return true
+	}
+
+	if matched, ok := f.exclude.Match(rel, dir); ok && matched {
+		return true
+	}
+
+	var result bool
+
+	// deeper .indexignore files take precedence
+	for _, d := range ancestors(rel) {
+		l, ok := f.ignores[d]
+
+		if !ok {
+			continue
+		}
+
+		if matched, ok := l.Match(rel, dir); ok {
+			result = matched
+		}
+	}
+
+	return result
+}
+
+// ancestors returns the directories containing rel, starting at the root ("")
+func ancestors(rel string) []string {
+	dirs := []string{""}
+
+	parts := strings.Split(rel, "/")
+
+	for i := 1; i < len(parts); i++ {
+		dirs = append(dirs, strings.Join(parts[:i], "/"))
+	}
+
+	return dirs
+}
@@ -0,0 +1,488 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/adrianliechti/wingman-index/pkg/config"
+	"github.com/adrianliechti/wingman-index/pkg/index"
+	"github.com/adrianliechti/wingman-index/pkg/indexer"
+
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+var _ indexer.Source = &Source{}
+
+// Source indexes the files of a git repository at a branch, tag or commit
+type Source struct {
+	url string
+	ref string
+
+	dir   string
+	link  string
+	token string
+
+	files config.Files
+
+	mu sync.Mutex
+
+	repo *gogit.Repository
+
+	// commit is the snapshot listed by the last call to Items
+	commit *object.Commit
+
+	// branch is the default branch of the remote
+	branch string
+
+	// modified holds the commit last modifying each file of the snapshot
+	modified map[string]*object.Commit
+	snapshot plumbing.Hash
+}
+
+type Option func(*Source)
+
+// WithReference selects the branch, tag or commit to index instead of the
+// default branch
+func WithReference(ref string) Option {
+	return func(s *Source) {
+		s.ref = ref
+	}
+}
+
+// WithDirectory keeps a bare clone in dir instead of in memory
+func WithDirectory(dir string) Option {
+	return func(s *Source) {
+		s.dir = dir
+	}
+}
+
+// WithToken authenticates against HTTP remotes
+func WithToken(token string) Option {
+	return func(s *Source) {
+		s.token = token
+	}
+}
+
+// WithLink sets the template of the web UI links to files. The placeholders
+// {ref}, {commit} and {path} are replaced. Links are derived from the URL for
+// GitHub, GitLab and Bitbucket remotes.
+func WithLink(link string) Option {
+	return func(s *Source) {
+		s.link = link
+	}
+}
+
+// WithFiles sets the rules selecting the files to index
+func WithFiles(files config.Files) Option {
+	return func(s *Source) {
+		s.files = files
+	}
+}
+
+// New returns a source for the repository at url, which is a remote URL or a
+// local path
+func New(url string, options ...Option) (*Source, error) {
+	if url == "" {
+		return nil, errors.New("repository url is required")
+	}
+
+	s := &Source{
+		url: url,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	if s.link == "" {
+		s.link = webLink(url)
+	}
+
+	return s, nil
+}
+
+func (s *Source) Items(ctx context.Context) iter.Seq2[indexer.Item, error] {
+	return func(yield func(indexer.Item, error) bool) {
+		items, err := s.list(ctx)
+
+		if err != nil {
+			yield(indexer.Item{}, err)
+			return
+		}
+
+		for _, item := range items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+// list updates the repository and returns the items of the current commit.
+// Items are opened while they are yielded, so the lock is not held then.
+func (s *Source) list(ctx context.Context) ([]indexer.Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.update(ctx); err != nil {
+		return nil, err
+	}
+
+	tree, err := s.commit.Tree()
+
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := newFilter(tree, s.files)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*object.File
+
+	err = tree.Files().ForEach(func(file *object.File) error {
+		if f.accepted(file.Name, file.Size) {
+			files = append(files, file)
+		}
+
+		return ctx.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.history(ctx, files); err != nil {
+		return nil, err
+	}
+
+	var items []indexer.Item
+
+	for _, file := range files {
+		item := indexer.Item{
+			Path: "/" + file.Name,
+
+			Revision: file.Hash.String(),
+
+			Size: file.Size,
+
+			Link: s.linkTo(file.Name),
+		}
+
+		// the metadata only changes along with the file, unlike the commit
+		// of the snapshot
+		if c, ok := s.modified[file.Name]; ok {
+			item.Time = c.Author.When
+
+			item.Metadata = index.Metadata{
+				"author":      c.Author.Name,
+				"last_commit": c.Hash.String(),
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (s *Source) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.commit == nil {
+		return nil, errors.New("repository not loaded")
+	}
+
+	file, err := s.commit.File(strings.TrimPrefix(path, "/"))
+
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, fmt.Errorf("%s: %w", path, fs.ErrNotExist)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return file.Reader()
+}
+
+// update clones the repository or fetches new commits and resolves the
+// configured reference
+func (s *Source) update(ctx context.Context) error {
+	if s.repo == nil {
+		repo, err := s.open(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		s.repo = repo
+	} else {
+		err := s.repo.FetchContext(ctx, &gogit.FetchOptions{
+			RefSpecs: []gitconfig.RefSpec{
+				"+refs/heads/*:refs/remotes/origin/*",
+				"+refs/tags/*:refs/tags/*",
+			},
+
+			Auth:  s.auth(),
+			Force: true,
+		})
+
+		if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+			return err
+		}
+	}
+
+	hash, err := s.resolve()
+
+	if err != nil {
+		return err
+	}
+
+	commit, err := s.repo.CommitObject(hash)
+
+	if err != nil {
+		return err
+	}
+
+	s.commit = commit
+
+	return nil
+}
+
+// open opens an existing clone or clones the repository
+func (s *Source) open(ctx context.Context) (*gogit.Repository, error) {
+	options := &gogit.CloneOptions{
+		URL:  s.url,
+		Auth: s.auth(),
+
+		Tags: gogit.AllTags,
+	}
+
+	if s.dir == "" {
+		return gogit.CloneContext(ctx, memory.NewStorage(), nil, options)
+	}
+
+	if repo, err := gogit.PlainOpen(s.dir); err == nil {
+		return repo, nil
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return gogit.PlainCloneContext(ctx, s.dir, true, options)
+}
+
+// resolve returns the commit of the configured reference, or of the default
+// branch of the remote
+func (s *Source) resolve() (plumbing.Hash, error) {
+	ref := s.ref
+
+	if ref == "" {
+		head, err := s.repo.Head()
+
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		s.branch = head.Name().Short()
+		ref = s.branch
+	}
+
+	candidates := []string{
+		"refs/remotes/origin/" + ref,
+		"refs/tags/" + ref,
+		ref,
+	}
+
+	for _, c := range candidates {
+		if hash, err := s.repo.ResolveRevision(plumbing.Revision(c)); err == nil {
+			return *hash, nil
+		}
+	}
+
+	return plumbing.ZeroHash, errors.New("reference not found: " + ref)
+}
+
+// maxHistory bounds the commits walked to find the last modification of
+// files; files not modified within them are listed without commit metadata
+const maxHistory = 1000
+
+// history finds the commit last modifying each of the files by walking the
+// history from the current commit. Results of the previous snapshot are kept
+// for files not changed since, and are reused as is while the commit stays
+// the same.
+func (s *Source) history(ctx context.Context, files []*object.File) error {
+	previous := s.modified
+
+	if previous != nil && s.snapshot == s.commit.Hash {
+		return nil
+	}
+
+	result := make(map[string]*object.Commit, len(files))
+
+	remaining := make(map[string]bool, len(files))
+
+	for _, f := range files {
+		remaining[f.Name] = true
+	}
+
+	iter, err := s.repo.Log(&gogit.LogOptions{From: s.commit.Hash})
+
+	if err != nil {
+		return err
+	}
+
+	defer iter.Close()
+
+	var walked int
+
+	err = iter.ForEach(func(c *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if len(remaining) == 0 {
+			return storer.ErrStop
+		}
+
+		// everything older is known from the previous snapshot
+		if previous != nil && c.Hash == s.snapshot {
+			for name := range remaining {
+				if p, ok := previous[name]; ok {
+					result[name] = p
+				}
+			}
+
+			return storer.ErrStop
+		}
+
+		if walked++; walked > maxHistory {
+			return storer.ErrStop
+		}
+
+		tree, err := c.Tree()
+
+		if err != nil {
+			return err
+		}
+
+		var parent *object.Tree
+
+		if c.NumParents() > 0 {
+			p, err := c.Parent(0)
+
+			if err != nil {
+				return err
+			}
+
+			if parent, err = p.Tree(); err != nil {
+				return err
+			}
+		}
+
+		changes, err := object.DiffTreeWithOptions(ctx, parent, tree, nil)
+
+		if err != nil {
+			return err
+		}
+
+		for _, change := range changes {
+			name := change.To.Name
+
+			if !remaining[name] {
+				continue
+			}
+
+			result[name] = c
+			delete(remaining, name)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	s.modified = result
+	s.snapshot = s.commit.Hash
+
+	return nil
+}
+
+func (s *Source) auth() transport.AuthMethod {
+	if s.token == "" {
+		return nil
+	}
+
+	return &http.BasicAuth{
+		Username: "git",
+		Password: s.token,
+	}
+}
+
+// linkTo returns the web UI link to the file at path, if a link template is set
+func (s *Source) linkTo(path string) string {
+	if s.link == "" {
+		return ""
+	}
+
+	ref := s.ref
+
+	if ref == "" {
+		ref = s.branch
+	}
+
+	return strings.NewReplacer(
+		"{ref}", ref,
+		"{commit}", s.commit.Hash.String(),
+		"{path}", path,
+	).Replace(s.link)
+}
+
+var remotePattern = regexp.MustCompile(`^(?:https?://(?:[^@/]+@)?|ssh://(?:[^@/]+@)?|[^@/]+@)([^/:]+)(?::\d+)?[/:](.+?)(?:\.git)?/?$`)
+
+// webLink derives the link template of well-known hosting services from the
+// remote url
+func webLink(url string) string {
+	m := remotePattern.FindStringSubmatch(url)
+
+	if m == nil {
+		return ""
+	}
+
+	host, repo := m[1], m[2]
+
+	switch {
+	case host == "github.com":
+		return "https://" + host + "/" + repo + "/blob/{ref}/{path}"
+
+	case strings.Contains(host, "gitlab"):
+		return "https://" + host + "/" + repo + "/-/blob/{ref}/{path}"
+
+	case host == "bitbucket.org":
+		return "https://" + host + "/" + repo + "/src/{ref}/{path}"
+	}
+
+	return ""
+}
@@ -0,0 +1,139 @@
+package git
+
+import (
+	"context"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/adrianliechti/wingman-index/pkg/indexer"
+
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSource(t *testing.T) {
+	ctx := context.Background()
+
+	work := t.TempDir()
+	bare := filepath.Join(t.TempDir(), "docs.git")
+
+	repo, err := gogit.PlainInit(work, false)
+	require.NoError(t, err)
+
+	first := commit(t, repo, work, "Alice", map[string]string{
+		"README.md":          "# Readme",
+		"docs/guide.md":      "# Guide",
+		"docs/image.png":     "binary",
+		"private/secret.md":  "# Secret",
+		".indexignore":       "private/",
+		".github/CONTRIB.md": "# Contributing",
+	})
+
+	_, err = gogit.PlainClone(bare, true, &gogit.CloneOptions{URL: work})
+	require.NoError(t, err)
+
+	s, err := New(bare, WithDirectory(filepath.Join(t.TempDir(), "clone")), WithLink("https://example.com/{ref}/{path}"))
+	require.NoError(t, err)
+
+	items := collect(t, s)
+	require.Equal(t, []string{"/README.md", "/docs/guide.md"}, slices.Sorted(maps.Keys(items)))
+
+	readme := items["/README.md"]
+
+	blob := plumbing.ComputeHash(plumbing.BlobObject, []byte("# Readme"))
+
+	require.Equal(t, blob.String(), readme.Revision)
+	require.NotContains(t, readme.Metadata, "commit")
+	require.Equal(t, first.String(), readme.Metadata["last_commit"])
+	require.Equal(t, "Alice", readme.Metadata["author"])
+	require.Equal(t, "https://example.com/master/README.md", readme.Link)
+
+	r, err := s.Open(ctx, "/docs/guide.md")
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(r)
+	r.Close()
+
+	require.NoError(t, err)
+	require.Equal(t, "# Guide", string(data))
+
+	// push a second commit changing only the guide
+	second := commit(t, repo, work, "Bob", map[string]string{
+		"docs/guide.md": "# Guide v2",
+	})
+
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{Name: "bare", URLs: []string{bare}})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Push(&gogit.PushOptions{RemoteName: "bare"}))
+
+	items = collect(t, s)
+
+	require.Equal(t, readme.Revision, items["/README.md"].Revision)
+	require.Equal(t, first.String(), items["/README.md"].Metadata["last_commit"])
+	require.Equal(t, "Alice", items["/README.md"].Metadata["author"])
+
+	guide := items["/docs/guide.md"]
+
+	require.Equal(t, second.String(), guide.Metadata["last_commit"])
+	require.Equal(t, "Bob", guide.Metadata["author"])
+}
+
+func TestWebLink(t *testing.T) {
+	for url, expected := range map[string]string{
+		"https://github.com/org/repo.git":   "https://github.com/org/repo/blob/{ref}/{path}",
+		"git@github.com:org/repo.git":       "https://github.com/org/repo/blob/{ref}/{path}",
+		"https://gitlab.com/group/sub/repo": "https://gitlab.com/group/sub/repo/-/blob/{ref}/{path}",
+		"https://bitbucket.org/org/repo":    "https://bitbucket.org/org/repo/src/{ref}/{path}",
+		"https://example.com/repo.git":      "",
+		"/srv/git/repo.git":                 "",
+	} {
+		require.Equal(t, expected, webLink(url), url)
+	}
+}
+
+func commit(t *testing.T, repo *gogit.Repository, dir, author string, files map[string]string) plumbing.Hash {
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+
+		_, err := wt.Add(name)
+		require.NoError(t, err)
+	}
+
+	hash, err := wt.Commit("update", &gogit.CommitOptions{
+		Author: &object.Signature{
+			Name:  author,
+			Email: author + "@example.com",
+			When:  time.Now(),
+		},
+	})
+
+	require.NoError(t, err)
+
+	return hash
+}
+
+func collect(t *testing.T, s *Source) map[string]indexer.Item {
+	result := map[string]indexer.Item{}
+
+	for item, err := range s.Items(context.Background()) {
+		require.NoError(t, err)
+		result[item.Path] = item
+	}
+
+	return result
+}
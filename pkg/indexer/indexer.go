@@ -1,306 +1,395 @@
 package indexer
 
 import (
-	"bytes"
 	"context"
-	"crypto/md5"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
 	"regexp"
-	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/adrianliechti/wingman-index/pkg/config"
 	"github.com/adrianliechti/wingman-index/pkg/index"
-	"github.com/adrianliechti/wingman-index/pkg/to"
-	"github.com/adrianliechti/wingman/pkg/client"
 )
 
 type Indexer struct {
 	*config.Config
+
+	sources []*source
+
+	extractor Extractor
+	segmenter Segmenter
+
+	// mu serializes indexing runs
+	mu sync.Mutex
+
+	// locks serializes work on content shared by items with identical content
+	locks sync.Map
+
+	// state records extracted content, embeddings and indexed documents
+	state *state
+
+	// refs records the content used by the running sync
+	refs *references
 }
 
-func New(cfg *config.Config) (*Indexer, error) {
-	indexer := &Indexer{
-		Config: cfg,
+// DefaultExtensions are indexed unless configured otherwise
+var DefaultExtensions = []string{
+	".csv",
+	".md",
+	".rst",
+	".tsv",
+	".txt",
+
+	".html", ".htm",
+	".json",
+
+	".pdf",
+
+	// ".jpg", ".jpeg",
+	// ".png",
+	// ".bmp",
+	// ".tiff",
+	// ".heif",
+
+	".docx",
+	".pptx",
+	".xlsx",
+
+	// source code, see Language
+	".go",
+	".py",
+	".js", ".jsx", ".mjs",
+	".ts", ".tsx",
+	".java",
+	".kt",
+	".cs",
+	".rb",
+	".rs",
+	".c", ".h",
+	".cc", ".cpp", ".hpp",
+	".php",
+	".swift",
+	".scala",
+	".sh",
+	".sql",
+}
+
+// fileState is the revision of an item at a given size and modification time
+type fileState struct {
+	Size int64     `json:"size"`
+	Time time.Time `json:"time"`
+
+	Revision string `json:"revision"`
+}
+
+// revision returns the known revision of an item if it did not change since
+func (idx *Indexer) revision(key string, item Item) (string, bool) {
+	var f fileState
+
+	if ok, err := idx.state.get(filesBucket, key, &f); !ok || err != nil {
+		return "", false
 	}
 
-	return indexer, nil
+	if f.Size != item.Size || !f.Time.Equal(item.Time) {
+		return "", false
+	}
+
+	return f.Revision, true
 }
 
-func (idx *Indexer) IndexDir(ctx context.Context, root string) error {
-	supported := []string{
-		".csv",
-		".md",
-		".rst",
-		".tsv",
-		".txt",
-
-		".pdf",
-
-		// ".jpg", ".jpeg",
-		// ".png",
-		// ".bmp",
-		// ".tiff",
-		// ".heif",
-
-		".docx",
-		".pptx",
-		".xlsx",
+type Option func(*Indexer)
+
+// WithExtractor replaces the extractors converting files to text. By default,
+// common formats are extracted locally and others by the wingman client.
+func WithExtractor(extractor Extractor) Option {
+	return func(idx *Indexer) {
+		idx.extractor = extractor
 	}
+}
 
-	var result error
+// WithSegmenter replaces the segmenter configured by the Segmenter settings
+func WithSegmenter(segmenter Segmenter) Option {
+	return func(idx *Indexer) {
+		idx.segmenter = segmenter
+	}
+}
 
-	revisions := map[string]string{}
+func New(cfg *config.Config, options ...Option) (*Indexer, error) {
+	if cfg.CacheDir == "" {
+		return nil, errors.New("cache directory is required")
+	}
 
-	filepath.WalkDir(root, func(path string, e fs.DirEntry, err error) error {
-		if err != nil {
-			result = errors.Join(result, err)
-			return nil
-		}
+	indexer := &Indexer{
+		Config: cfg,
+	}
 
-		if strings.Contains(path, ".cache") {
-			return nil
+	for _, option := range options {
+		option(indexer)
+	}
+
+	if indexer.extractor == nil {
+		extractors := Extractors{
+			NewLocalExtractor(),
 		}
 
-		if e.IsDir() || !slices.Contains(supported, filepath.Ext(path)) {
-			return nil
+		if cfg.Client != nil {
+			extractors = append(extractors, NewClientExtractor(cfg.Client))
 		}
 
-		data, err := os.ReadFile(path)
+		indexer.extractor = extractors
+	}
 
-		if err != nil {
-			result = errors.Join(result, err)
-			return nil
-		}
+	if indexer.segmenter == nil {
+		switch cfg.Segmenter {
+		case "wingman":
+			if cfg.Client == nil {
+				return nil, errors.New("wingman segmenter requires a client")
+			}
+
+			indexer.segmenter = NewClientSegmenter(cfg.Client, cfg.SegmentSize, cfg.SegmentOverlap)
 
-		md5_hash := md5.Sum(data)
-		md5_text := hex.EncodeToString(md5_hash[:])
+		default:
+			options := []SegmenterOption{
+				WithSegmentSize(cfg.SegmentSize),
+				WithSegmentOverlap(cfg.SegmentOverlap),
+			}
 
-		cachedir := filepath.Join(root, ".cache", md5_text[0:2], md5_text[2:4], md5_text)
-		os.MkdirAll(cachedir, 0755)
+			if cfg.SegmentSize <= 0 {
+				options = nil
+			}
 
-		info, err := e.Info()
+			if cfg.SegmentTokens {
+				options = append(options, WithTokenLength())
+			}
 
-		if err != nil {
-			result = errors.Join(result, err)
-			return nil
+			indexer.segmenter = NewTextSegmenter(options...)
 		}
 
-		rel, _ := filepath.Rel(root, path)
+		size := cfg.SegmentSize
 
-		name := filepath.Base(path)
-		title := strings.TrimSuffix(name, filepath.Ext(name))
-		revision := md5_text
+		if size <= 0 {
+			size = 2000
+		}
 
-		// Extract title from markdown files
-		if strings.ToLower(filepath.Ext(path)) == ".md" {
-			if mdTitle := extractMarkdownTitle(data); mdTitle != "" {
-				title = mdTitle
-			}
+		if cfg.SegmentTokens {
+			size *= 4
 		}
 
-		metadata := Metadata{
-			Name: filepath.Base(path),
-			Path: "/" + rel,
+		indexer.segmenter = NewCodeSegmenter(indexer.segmenter, size)
+	}
 
-			Title:    title,
-			Revision: revision,
+	state, err := openState(cfg.CacheDir)
 
-			Size: info.Size(),
-			Time: info.ModTime(),
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		if !exists(cachedir, "metadata.json") {
-			if err := writeJSON(cachedir, "metadata.json", metadata); err != nil {
-				result = errors.Join(result, err)
-				return nil
-			}
-		}
+	indexer.state = state
 
-		if !exists(cachedir, "content.txt") {
-			body := client.ExtractionRequest{
-				Name:   metadata.Name,
-				Reader: bytes.NewReader(data),
-			}
+	return indexer, nil
+}
 
-			content, err := idx.Client.Extractions.New(ctx, body)
+// Close releases the state database
+func (idx *Indexer) Close() error {
+	return idx.state.Close()
+}
 
-			if err != nil {
-				result = errors.Join(result, err)
-				return nil
-			}
+// Sync indexes all sources into their namespaces
+func (idx *Indexer) Sync(ctx context.Context) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
 
-			if err := writeData(cachedir, "content.txt", []byte(content.Text)); err != nil {
-				result = errors.Join(result, err)
-				return nil
-			}
-		}
+	model, err := idx.probeEmbedder(ctx)
 
-		if idx.Embedder != nil && !exists(cachedir, "embeddings.json") {
-			text, err := readText(cachedir, "content.txt")
+	if err != nil {
+		return err
+	}
 
-			if err != nil {
-				result = errors.Join(result, err)
-				return nil
-			}
+	refs := &references{}
 
-			segments, err := idx.Client.Segments.New(ctx, client.SegmentRequest{
-				Name:   "content.txt",
-				Reader: strings.NewReader(text),
+	idx.refs = refs
+	defer func() { idx.refs = nil }()
 
-				SegmentLength:  to.Ptr(3000),
-				SegmentOverlap: to.Ptr(1500),
-			})
+	var result error
 
-			if err != nil {
-				result = errors.Join(result, err)
-				return nil
-			}
+	for _, namespace := range idx.namespaces() {
+		if err := idx.indexNamespace(ctx, namespace, model); err != nil {
+			result = errors.Join(result, err)
+		}
+	}
 
-			embeddings := Embeddings{}
+	if ctx.Err() == nil {
+		if err := idx.collect(refs); err != nil {
+			log.Printf("Error collecting cache entries: %v\n", err)
+		}
+	}
 
-			titleEmbedding, err := idx.Embedder.Embed(ctx, []string{title})
+	return result
+}
 
-			if err != nil {
-				result = errors.Join(result, err)
-				return nil
-			}
+func (idx *Indexer) indexNamespace(ctx context.Context, namespace string, model *embeddingModel) error {
+	target, err := idx.target(namespace)
 
-			if embeddings.Model == "" {
-				embeddings.Model = titleEmbedding.Model
-			}
+	if err != nil {
+		idx.refs.fail()
+		return err
+	}
 
-			embeddings.Segments = append(embeddings.Segments, Segment{
-				Text:      title,
-				Embedding: titleEmbedding.Embeddings[0],
-			})
+	if target != nil {
+		if err := idx.ensureAlias(ctx, namespace, model); err != nil {
+			idx.refs.fail()
+			return err
+		}
 
-			for _, segment := range segments {
-				segmentEmbedding, err := idx.Embedder.Embed(ctx, []string{segment.Text})
+		m, err := checkIndex(ctx, target, model)
 
-				if err != nil {
-					result = errors.Join(result, err)
-					return nil
-				}
+		if err != nil {
+			idx.refs.fail()
+			return err
+		}
 
-				embeddings.Segments = append(embeddings.Segments, Segment{
-					Text:      segment.Text,
-					Embedding: segmentEmbedding.Embeddings[0],
-				})
+		if m != nil {
+			if err := idx.migrate(ctx, namespace, model, m); err != nil {
+				idx.refs.fail()
+				return err
 			}
 
-			if err := writeJSON(cachedir, "embeddings.json", embeddings); err != nil {
-				result = errors.Join(result, err)
-				return nil
-			}
+			return nil
 		}
+	}
 
-		if idx.Index != nil && !exists(cachedir, "documents.json") {
-			var embeddings Embeddings
+	_, err = idx.indexSources(ctx, namespace, namespace, target, model, false)
+	return err
+}
 
-			if err := readJSON(cachedir, "embeddings.json", &embeddings); err != nil {
-				result = errors.Join(result, err)
-				return nil
-			}
+// report summarizes an indexing run
+type report struct {
+	Files     int
+	Documents int
 
-			var documents []index.Document
+	// Missing counts files pushed again because their documents were missing
+	// from the index; Orphans counts documents deleted because no file
+	// accounts for them
+	Missing int
+	Orphans int
 
-			for i, segment := range embeddings.Segments {
-				document := index.Document{
-					Title:  metadata.Title,
-					Source: fmt.Sprintf("%s#%d", metadata.Path, i+1),
+	// Titles of a few indexed files, used as sample queries
+	Titles []string
+}
 
-					Content:   segment.Text,
-					Embedding: segment.Embedding,
+func (r *report) add(other *report) {
+	r.Files += other.Files
+	r.Documents += other.Documents
 
-					Metadata: map[string]string{
-						"filename": metadata.Name,
-						"filepath": metadata.Path,
+	r.Missing += other.Missing
+	r.Orphans += other.Orphans
 
-						"index":    fmt.Sprintf("%d", i),
-						"revision": metadata.Revision,
-					},
-				}
+	for _, title := range other.Titles {
+		if len(r.Titles) < 3 {
+			r.Titles = append(r.Titles, title)
+		}
+	}
+}
 
-				if err := idx.Index.Index(ctx, document); err != nil {
-					result = errors.Join(result, err)
-					return nil
-				}
+// indexSources indexes all sources bound to namespace into target and
+// reconciles the documents of target afterwards. What was pushed is recorded
+// under the records namespace.
+func (idx *Indexer) indexSources(ctx context.Context, namespace, records string, target index.Provider, model *embeddingModel, force bool) (*report, error) {
+	var result error
 
-				documents = append(documents, document)
-			}
+	total := &report{}
 
-			if err != writeJSON(cachedir, "documents.json", documents) {
-				result = errors.Join(result, err)
-				return nil
-			}
+	var runs []*pipeline
+
+	for _, src := range idx.sources {
+		if src.Namespace != namespace {
+			continue
 		}
 
-		revisions[metadata.Path] = metadata.Revision
+		p, report, err := idx.indexSource(ctx, src, records, target, model, force)
 
-		println(metadata.Path, metadata.Revision)
+		if err != nil {
+			result = errors.Join(result, fmt.Errorf("source %s: %w", src.Name, err))
+		}
 
-		return nil
-	})
+		total.add(report)
 
-	if idx != nil {
-		var cursor string
+		runs = append(runs, p)
+	}
 
-		var list []index.Document
+	if target == nil || ctx.Err() != nil {
+		return total, result
+	}
 
-		for {
-			page, err := idx.Index.List(ctx, &index.ListOptions{
-				Limit:  to.Ptr(10),
-				Cursor: cursor,
-			})
+	report, err := idx.reconcile(ctx, records, target, model, runs)
 
-			if err != nil {
-				return err
-			}
+	if err != nil {
+		result = errors.Join(result, err)
+	}
 
-			list = append(list, page.Items...)
+	total.add(report)
 
-			cursor = page.Cursor
+	return total, result
+}
 
-			if cursor == "" {
-				break
-			}
-		}
+// indexSource indexes all items of src into target using vectors of the given
+// model. With force, documents are pushed even if the state records them as
+// already indexed.
+func (idx *Indexer) indexSource(ctx context.Context, src *source, records string, target index.Provider, model *embeddingModel, force bool) (*pipeline, *report, error) {
+	p := &pipeline{
+		Indexer: idx,
 
-		var deletions []string
+		src:    src,
+		target: target,
 
-		for _, d := range list {
-			filepath := d.Metadata["filepath"]
-			revision := d.Metadata["revision"]
+		namespace: records,
 
-			if filepath == "" || revision == "" {
-				continue
-			}
+		model: model,
+		force: force,
 
-			ref := revisions[filepath]
+		items:     map[string]Item{},
+		revisions: map[string]string{},
+	}
 
-			if strings.EqualFold(revision, ref) {
-				continue
-			}
+	report := &report{}
 
-			deletions = append(deletions, d.ID)
+	for j := range p.run(ctx, p.discover(ctx)) {
+		p.items[j.metadata.Path] = j.item
+		p.revisions[j.metadata.Path] = j.metadata.Revision
+
+		report.Files++
+		report.Documents += j.count
+
+		if j.count > 0 && len(report.Titles) < 3 {
+			report.Titles = append(report.Titles, j.metadata.Title)
 		}
 
-		if len(deletions) > 0 {
-			if err := idx.Index.Delete(ctx, deletions...); err != nil {
-				return err
-			}
+		if j.count > 0 {
+			log.Printf("indexed %s (%d documents)", j.metadata.Path, j.count)
 		}
 	}
 
-	return result
+	if p.incomplete {
+		idx.refs.fail()
+	}
+
+	return p, report, errors.Join(p.err, ctx.Err())
+}
+
+// lock acquires the lock of a content key and returns its release function
+func (idx *Indexer) lock(key string) func() {
+	v, _ := idx.locks.LoadOrStore(key, &sync.Mutex{})
+
+	mu := v.(*sync.Mutex)
+	mu.Lock()
+
+	return mu.Unlock
 }
 
 type Metadata struct {
@@ -321,9 +410,21 @@ type Embeddings struct {
 	Segments []Segment `json:"segments"`
 }
 
+// Dimension returns the vector dimension of the embeddings
+func (e *Embeddings) Dimension() int {
+	if len(e.Segments) == 0 {
+		return 0
+	}
+
+	return len(e.Segments[0].Embedding)
+}
+
 type Segment struct {
 	Text string `json:"text"`
 
+	// Metadata is added to the document of the segment
+	Metadata index.Metadata `json:"metadata,omitempty"`
+
 	Embedding []float32 `json:"embedding"`
 }
 
@@ -365,20 +466,6 @@ func readJSON(dir, name string, v any) error {
 	return json.Unmarshal(data, v)
 }
 
-func writeData(dir, name string, data []byte) error {
-	return os.WriteFile(filepath.Join(dir, name), data, 0644)
-}
-
-func writeJSON(dir, name string, v any) error {
-	data, err := json.MarshalIndent(v, "", "  ")
-
-	if err != nil {
-		return err
-	}
-
-	return writeData(dir, name, data)
-}
-
 func extractMarkdownTitle(data []byte) string {
 	content := string(data)
 	lines := strings.Split(content, "\n")
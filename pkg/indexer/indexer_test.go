@@ -0,0 +1,561 @@
+package indexer
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adrianliechti/wingman-index/pkg/config"
+	"github.com/adrianliechti/wingman-index/pkg/index"
+	"github.com/adrianliechti/wingman-index/pkg/index/memory"
+	"github.com/adrianliechti/wingman-index/pkg/to"
+	"github.com/adrianliechti/wingman-index/test"
+
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestSync(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	idx, err := New(&config.Config{
+		Namespace: "default",
+
+		Index:    c,
+		Embedder: context.Embedder,
+
+		BatchSize: 8,
+
+		ExtractConcurrency: 1,
+		SegmentConcurrency: 1,
+		EmbedConcurrency:   1,
+		UpsertConcurrency:  1,
+
+		CacheDir: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	defer idx.Close()
+
+	src := &testSource{
+		items: map[string]string{
+			"/guide.md":  "# Guide\n\nHello world.",
+			"/notes.txt": "Some notes.",
+		},
+	}
+
+	require.NoError(t, idx.AddSource(SourceConfig{Name: "docs", Source: src}))
+	require.NoError(t, idx.Sync(context.Context))
+
+	documents := listDocuments(t, c)
+
+	require.Equal(t, []string{"/guide.md#1", "/guide.md#2", "/notes.txt#1", "/notes.txt#2"}, sources(documents))
+	require.Equal(t, "Guide\n\nHello world.", documents[1].Content)
+	require.Equal(t, "Guide", documents[1].Title)
+
+	delete(src.items, "/notes.txt")
+
+	require.NoError(t, idx.Sync(context.Context))
+	require.Equal(t, []string{"/guide.md#1", "/guide.md#2"}, sources(listDocuments(t, c)))
+
+	// the state of deleted files is removed along with their documents
+	require.False(t, has(t, idx, filesBucket, "docs:/notes.txt"))
+	require.True(t, has(t, idx, filesBucket, "docs:/guide.md"))
+}
+
+func TestSyncReconcile(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	idx, err := New(&config.Config{
+		Namespace: "default",
+
+		Index:    c,
+		Embedder: context.Embedder,
+
+		BatchSize: 8,
+
+		CacheDir: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	defer idx.Close()
+
+	src := &testSource{
+		items: map[string]string{
+			"/guide.md":  "# Guide\n\nHello world.",
+			"/notes.txt": "Some notes.",
+		},
+	}
+
+	require.NoError(t, idx.AddSource(SourceConfig{Name: "docs", Source: src}))
+	require.NoError(t, idx.Sync(context.Context))
+
+	documents := listDocuments(t, c)
+	require.Len(t, documents, 4)
+
+	guide := documents[0]
+
+	// the backend lost a document and gained others no file accounts for
+	require.NoError(t, c.Delete(context.Context, documents[1].ID))
+
+	require.NoError(t, c.Index(context.Context,
+		index.Document{ID: "stray", Content: "stray"},
+		index.Document{ID: "duplicate", Content: "duplicate", Metadata: index.Metadata{"source": "docs", "filepath": "/guide.md", "revision": guide.Metadata.String("revision")}},
+		index.Document{ID: "removed", Content: "removed", Metadata: index.Metadata{"source": "old", "filepath": "/old.md", "revision": "1"}},
+	))
+
+	require.NoError(t, idx.Sync(context.Context))
+
+	documents = listDocuments(t, c)
+
+	require.Equal(t, []string{"/guide.md#1", "/guide.md#2", "/notes.txt#1", "/notes.txt#2"}, sources(documents))
+	require.Equal(t, guide.ID, documents[0].ID)
+	require.Equal(t, "Guide\n\nHello world.", documents[1].Content)
+
+	// files pushed again are recorded in their namespace
+	require.Len(t, allRecords(t, idx), 2)
+}
+
+// truncatedIndex lists at most one document without a cursor, like backends
+// capping their listings, and counts the indexed documents
+type truncatedIndex struct {
+	*memory.Provider
+
+	indexed int
+}
+
+func (c *truncatedIndex) List(ctx context.Context, options *index.ListOptions) (*index.Page[index.Document], error) {
+	page, err := c.Provider.List(ctx, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	page.Items = page.Items[:min(1, len(page.Items))]
+
+	return page, nil
+}
+
+func (c *truncatedIndex) Index(ctx context.Context, documents ...index.Document) error {
+	c.indexed += len(documents)
+	return c.Provider.Index(ctx, documents...)
+}
+
+func TestSyncReconcileTruncatedListing(t *testing.T) {
+	context := test.NewContext()
+
+	m, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	c := &truncatedIndex{Provider: m}
+
+	idx, err := New(&config.Config{
+		Namespace: "default",
+
+		Index:    c,
+		Embedder: context.Embedder,
+
+		CacheDir: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	defer idx.Close()
+
+	src := &testSource{
+		items: map[string]string{
+			"/guide.md":  "# Guide\n\nHello world.",
+			"/notes.txt": "Some notes.",
+		},
+	}
+
+	require.NoError(t, idx.AddSource(SourceConfig{Name: "docs", Source: src}))
+	require.NoError(t, idx.Sync(context.Context))
+	require.Equal(t, 4, c.indexed)
+
+	// documents missing from the listing are looked up instead of pushed again
+	require.NoError(t, idx.Sync(context.Context))
+	require.Equal(t, 4, c.indexed)
+
+	documents := listDocuments(t, m)
+	require.NoError(t, m.Delete(context.Context, documents[0].ID))
+
+	require.NoError(t, idx.Sync(context.Context))
+	require.Equal(t, 6, c.indexed)
+	require.Len(t, listDocuments(t, m), 4)
+}
+
+func TestSyncIdenticalContent(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	idx, err := New(&config.Config{
+		Namespace: "default",
+
+		Index:    c,
+		Embedder: context.Embedder,
+
+		BatchSize: 8,
+
+		CacheDir: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	defer idx.Close()
+
+	src := &testSource{
+		items: map[string]string{
+			"/guide.md":      "# Guide\n\nHello world.",
+			"/copy/guide.md": "# Guide\n\nHello world.",
+		},
+	}
+
+	require.NoError(t, idx.AddSource(SourceConfig{Name: "docs", Source: src}))
+	require.NoError(t, idx.Sync(context.Context))
+
+	documents := listDocuments(t, c)
+
+	require.Equal(t, []string{"/copy/guide.md#1", "/copy/guide.md#2", "/guide.md#1", "/guide.md#2"}, sources(documents))
+	require.Equal(t, "/copy/guide.md", documents[0].Metadata["filepath"])
+	require.Equal(t, "/guide.md", documents[2].Metadata["filepath"])
+
+	revision := documents[2].Metadata.String("revision")
+	require.Equal(t, documentID("docs", "/guide.md", revision, 0), documents[2].ID)
+	require.NotEqual(t, documents[0].ID, documents[2].ID)
+
+	copies := []string{documents[0].ID, documents[1].ID}
+
+	// changing one file leaves the documents of the other alone
+	src.items["/guide.md"] = "# Guide\n\nHello there again."
+
+	require.NoError(t, idx.Sync(context.Context))
+
+	documents = listDocuments(t, c)
+
+	require.Equal(t, []string{"/copy/guide.md#1", "/copy/guide.md#2", "/guide.md#1", "/guide.md#2"}, sources(documents))
+	require.Equal(t, copies, []string{documents[0].ID, documents[1].ID})
+	require.Equal(t, "Guide\n\nHello there again.", documents[3].Content)
+}
+
+func TestSyncSharedPaths(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	idx, err := New(&config.Config{
+		Namespace: "default",
+
+		Index:    c,
+		Embedder: context.Embedder,
+
+		CacheDir: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	defer idx.Close()
+
+	a := &testSource{items: map[string]string{"/readme.md": "# A\n\nFirst source."}}
+	b := &testSource{items: map[string]string{"/readme.md": "# B\n\nSecond source."}}
+
+	// sources without prefixes share the paths of the namespace
+	require.NoError(t, idx.AddSource(SourceConfig{Name: "a", Source: a}))
+	require.NoError(t, idx.AddSource(SourceConfig{Name: "b", Source: b}))
+
+	require.NoError(t, idx.Sync(context.Context))
+	require.NoError(t, idx.Sync(context.Context))
+
+	documents := listDocuments(t, c)
+	require.Len(t, documents, 4)
+
+	records, err := idx.state.records("default")
+	require.NoError(t, err)
+	require.Len(t, records["a"], 1)
+	require.Len(t, records["b"], 1)
+
+	delete(a.items, "/readme.md")
+
+	require.NoError(t, idx.Sync(context.Context))
+
+	documents = listDocuments(t, c)
+	require.Len(t, documents, 2)
+
+	for _, d := range documents {
+		require.Equal(t, "b", d.Metadata.String("source"))
+	}
+}
+
+func TestSyncMetadata(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	idx, err := New(&config.Config{
+		Namespace: "default",
+
+		Index:    c,
+		Embedder: context.Embedder,
+
+		BatchSize: 8,
+
+		ExtractConcurrency: 1,
+		SegmentConcurrency: 1,
+		EmbedConcurrency:   1,
+		UpsertConcurrency:  1,
+
+		CacheDir: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	defer idx.Close()
+
+	src := &testSource{
+		items: map[string]string{
+			"/_meta.yaml":               "team: docs\nproduct: wingman\n",
+			"/ops/_meta.yaml":           "team: ops\n",
+			"/ops/runbook.md":           "---\ntitle: Runbook\ntags: [oncall]\nsource: ignored\n---\n# Heading\n\nRestart it.",
+			"/ops/runbook.md.meta.json": `{"owner": "alice", "priority": 2, "weight": 0.5}`,
+			"/notes.txt":                "Some notes.",
+		},
+	}
+
+	require.NoError(t, idx.AddSource(SourceConfig{Name: "docs", Source: src}))
+	require.NoError(t, idx.Sync(context.Context))
+
+	documents := listDocuments(t, c)
+
+	require.Equal(t, []string{"/notes.txt#1", "/notes.txt#2", "/ops/runbook.md#1", "/ops/runbook.md#2"}, sources(documents))
+
+	require.Equal(t, "docs", documents[0].Metadata["team"])
+	require.Equal(t, "wingman", documents[0].Metadata["product"])
+
+	runbook := documents[3]
+
+	require.Equal(t, "Runbook", runbook.Title)
+	require.Equal(t, "Heading\n\nRestart it.", runbook.Content)
+	require.Equal(t, "ops", runbook.Metadata["team"])
+	require.Equal(t, "wingman", runbook.Metadata["product"])
+	require.Equal(t, "alice", runbook.Metadata["owner"])
+	require.Equal(t, int64(2), runbook.Metadata["priority"])
+	require.Equal(t, 0.5, runbook.Metadata["weight"])
+	require.Equal(t, "docs", runbook.Metadata["source"])
+	require.EqualValues(t, []string{"oncall"}, runbook.Metadata["tags"])
+
+	// changed sidecars update the documents without a content change
+	src.items["/ops/runbook.md.meta.json"] = `{"owner": "bob"}`
+
+	require.NoError(t, idx.Sync(context.Context))
+
+	documents = listDocuments(t, c)
+	require.Equal(t, "bob", documents[3].Metadata["owner"])
+
+	// as do changes of the metadata set by the source
+	src.metadata = map[string]index.Metadata{"/notes.txt": {"author": "carol"}}
+
+	require.NoError(t, idx.Sync(context.Context))
+
+	documents = listDocuments(t, c)
+	require.Equal(t, "carol", documents[0].Metadata["author"])
+}
+
+func TestSyncCollectsCache(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+
+	idx, err := New(&config.Config{
+		Namespace: "default",
+
+		Index:    c,
+		Embedder: context.Embedder,
+
+		BatchSize: 8,
+
+		CacheDir:       dir,
+		CacheRetention: time.Hour,
+	})
+	require.NoError(t, err)
+
+	defer idx.Close()
+
+	src := &testSource{
+		items: map[string]string{
+			"/guide.md":  "# Guide\n\nHello world.",
+			"/notes.txt": "Some notes.",
+		},
+	}
+
+	require.NoError(t, idx.AddSource(SourceConfig{Name: "docs", Source: src}))
+	require.NoError(t, idx.Sync(context.Context))
+
+	key := func(content string) string {
+		hash := md5.Sum([]byte(content))
+		return hex.EncodeToString(hash[:])
+	}
+
+	notes := key("Some notes.")
+	guide := key("# Guide\n\nHello world.")
+
+	require.True(t, has(t, idx, contentBucket, notes))
+
+	other := filepath.Join(dir, "git", "docs")
+	require.NoError(t, os.MkdirAll(other, 0755))
+
+	legacy := filepath.Join(dir, "ab", "cd", "abcd"+strings.Repeat("0", 28))
+	require.NoError(t, os.MkdirAll(legacy, 0755))
+
+	// unused content is kept within the retention period
+	delete(src.items, "/notes.txt")
+
+	require.NoError(t, idx.Sync(context.Context))
+	require.True(t, has(t, idx, contentBucket, notes))
+
+	past, err := time.Now().Add(-2 * time.Hour).MarshalText()
+	require.NoError(t, err)
+
+	require.NoError(t, idx.state.db.Update(func(tx *bolt.Tx) error {
+		tx.Bucket(usedBucket).Put([]byte(notes), past)
+		tx.Bucket(usedBucket).Put([]byte(guide), past)
+
+		return nil
+	}))
+
+	require.NoError(t, os.Chtimes(legacy, time.Now().Add(-2*time.Hour), time.Now().Add(-2*time.Hour)))
+
+	require.NoError(t, idx.Sync(context.Context))
+
+	require.False(t, has(t, idx, contentBucket, notes))
+	require.True(t, has(t, idx, contentBucket, guide))
+
+	require.NoDirExists(t, legacy)
+	require.DirExists(t, other)
+}
+
+func TestSyncState(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+
+	content := "# Guide\n\nHello world."
+
+	hash := md5.Sum([]byte(content))
+	key := hex.EncodeToString(hash[:])
+
+	// cache directories of earlier versions are imported
+	legacy := filepath.Join(dir, key[0:2], key[2:4], key)
+
+	require.NoError(t, os.MkdirAll(legacy, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(legacy, "content.txt"), []byte("Cached text."), 0644))
+
+	idx, err := New(&config.Config{
+		Namespace: "default",
+
+		Index:    c,
+		Embedder: context.Embedder,
+
+		BatchSize: 8,
+
+		CacheDir: dir,
+	})
+	require.NoError(t, err)
+
+	defer idx.Close()
+
+	src := &testSource{
+		items: map[string]string{
+			"/guide.md": content,
+		},
+	}
+
+	require.NoError(t, idx.AddSource(SourceConfig{Name: "docs", Source: src}))
+	require.NoError(t, idx.Sync(context.Context))
+
+	require.NoDirExists(t, legacy)
+
+	documents := listDocuments(t, c)
+
+	require.Len(t, documents, 2)
+	require.Equal(t, "Cached text.", documents[1].Content)
+
+	records, err := idx.state.records("default")
+	require.NoError(t, err)
+	require.Len(t, records["docs"], 1)
+
+	r := records["docs"]["/guide.md"]
+
+	require.Equal(t, "docs", r.Source)
+	require.Equal(t, key, r.Revision)
+	require.Equal(t, []string{documents[0].ID, documents[1].ID}, r.Documents)
+	require.Len(t, r.Chunks, 2)
+
+	// deleted items lose their record
+	delete(src.items, "/guide.md")
+
+	require.NoError(t, idx.Sync(context.Context))
+
+	records, err = idx.state.records("default")
+	require.NoError(t, err)
+	require.Empty(t, records)
+}
+
+func listDocuments(t *testing.T, c index.Provider) []index.Document {
+	var result []index.Document
+
+	var cursor string
+
+	for {
+		page, err := c.List(test.NewContext().Context, &index.ListOptions{Limit: to.Ptr(10), Cursor: cursor})
+		require.NoError(t, err)
+
+		result = append(result, page.Items...)
+
+		if page.Cursor == "" {
+			break
+		}
+
+		cursor = page.Cursor
+	}
+
+	slices.SortFunc(result, func(a, b index.Document) int {
+		return strings.Compare(a.Source, b.Source)
+	})
+
+	return result
+}
+
+func has(t *testing.T, idx *Indexer, bucket []byte, key string) bool {
+	ok, err := idx.state.has(bucket, key)
+	require.NoError(t, err)
+
+	return ok
+}
+
+func sources(documents []index.Document) []string {
+	var result []string
+
+	for _, d := range documents {
+		result = append(result, d.Source)
+	}
+
+	return result
+}
@@ -0,0 +1,254 @@
+package local
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrianliechti/wingman-index/pkg/config"
+	"github.com/adrianliechti/wingman-index/pkg/indexer"
+)
+
+// filter decides which files below a root are indexed, based on the configured
+// file rules and the .indexignore files found in the tree
+type filter struct {
+	config.Files
+
+	root string
+
+	include *indexer.Patterns
+	exclude *indexer.Patterns
+
+	// ignores caches the parsed .indexignore file of each directory
+	ignores map[string]*indexer.Patterns
+}
+
+func newFilter(root string, files config.Files) *filter {
+	f := &filter{
+		Files: files,
+
+		root: root,
+
+		include: indexer.CompilePatterns("", files.Include),
+		exclude: indexer.CompilePatterns("", files.Exclude),
+
+		ignores: make(map[string]*indexer.Patterns),
+	}
+
+	return f
+}
+
+func (f *filter) rel(p string) string {
+	rel, _ := filepath.Rel(f.root, p)
+	return filepath.ToSlash(rel)
+}
+
+// excluded reports whether the file or directory rel is excluded by its name,
+// the exclude patterns or an .indexignore file. Parent directories are not
+// checked.
+func (f *filter) excluded(rel string, dir bool) bool {
+	name := path.Base(rel)
+
+	if name == ".cache" || name == indexer.IgnoreFile {
+		return true
+	}
+
+	if !f.Hidden && strings.HasPrefix(name, ".") {
+		return true
+	}
+
+	if matched, ok := f.exclude.Match(rel, dir); ok && matched {
+		return true
+	}
+
+	var result bool
+
+	// deeper .indexignore files take precedence
+	for _, d := range ancestors(rel) {
+		if matched, ok := f.ignoreList(d).Match(rel, dir); ok {
+			result = matched
+		}
+	}
+
+	return result
+}
+
+// accepted reports whether the file rel has an indexed extension and matches
+// the include patterns
+func (f *filter) accepted(rel string) bool {
+	if !indexer.Supported(rel, f.Extensions) {
+		return false
+	}
+
+	if f.excluded(rel, false) {
+		return false
+	}
+
+	if !f.include.Empty() && !f.include.MatchAny(rel) {
+		return false
+	}
+
+	return true
+}
+
+// allowed reports whether the file at p is indexed, checking all its parent
+// directories. Files that no longer exist are checked by name only.
+func (f *filter) allowed(p string) bool {
+	rel := f.rel(p)
+
+	if rel == "." || strings.HasPrefix(rel, "../") {
+		return false
+	}
+
+	if info, err := os.Lstat(p); err == nil {
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if !f.Symlinks {
+				return false
+			}
+
+			if info, err = os.Stat(p); err != nil {
+				return false
+			}
+		}
+
+		if info.IsDir() {
+			return false
+		}
+
+		if f.MaxSize > 0 && info.Size() > f.MaxSize {
+			return false
+		}
+	}
+
+	if f.excludedDir(path.Dir(rel)) {
+		return false
+	}
+
+	return f.accepted(rel)
+}
+
+// excludedDir reports whether the directory rel or one of its parents is excluded
+func (f *filter) excludedDir(rel string) bool {
+	for d := rel; d != "." && d != ""; d = path.Dir(d) {
+		if f.excluded(d, true) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *filter) ignoreList(dir string) *indexer.Patterns {
+	if l, ok := f.ignores[dir]; ok {
+		return l
+	}
+
+	l, err := indexer.ReadPatterns(dir, filepath.Join(f.root, filepath.FromSlash(dir), indexer.IgnoreFile))
+
+	if err != nil {
+		l = indexer.CompilePatterns(dir, nil)
+	}
+
+	f.ignores[dir] = l
+
+	return l
+}
+
+// walk calls fn for every indexed file below dir. Symbolic links are followed
+// if enabled; directories reached twice through links are skipped.
+func (f *filter) walk(ctx context.Context, dir string, fn func(path string, e fs.DirEntry, err error) error) error {
+	return f.walkDir(ctx, dir, map[string]bool{}, fn)
+}
+
+func (f *filter) walkDir(ctx context.Context, dir string, visited map[string]bool, fn func(path string, e fs.DirEntry, err error) error) error {
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		if visited[real] {
+			return nil
+		}
+
+		visited[real] = true
+	}
+
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		return fn(dir, nil, err)
+	}
+
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		p := filepath.Join(dir, e.Name())
+		rel := f.rel(p)
+
+		if e.Type()&fs.ModeSymlink != 0 {
+			if !f.Symlinks {
+				continue
+			}
+
+			info, err := os.Stat(p)
+
+			if err != nil {
+				continue
+			}
+
+			e = fs.FileInfoToDirEntry(info)
+		}
+
+		if e.IsDir() {
+			if f.excluded(rel, true) {
+				continue
+			}
+
+			if err := f.walkDir(ctx, p, visited, fn); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if !f.accepted(rel) {
+			continue
+		}
+
+		if f.MaxSize > 0 {
+			info, err := e.Info()
+
+			if err != nil {
+				if err := fn(p, nil, err); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if info.Size() > f.MaxSize {
+				continue
+			}
+		}
+
+		if err := fn(p, e, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ancestors returns the directories containing rel, starting at the root ("")
+func ancestors(rel string) []string {
+	dirs := []string{""}
+
+	parts := strings.Split(rel, "/")
+
+	for i := 1; i < len(parts); i++ {
+		dirs = append(dirs, strings.Join(parts[:i], "/"))
+	}
+
+	return dirs
+}
@@ -0,0 +1,112 @@
+package local
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrianliechti/wingman-index/pkg/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterWalk(t *testing.T) {
+	root := t.TempDir()
+
+	files := map[string]string{
+		"README.md":                  "readme",
+		"notes.txt":                  "notes",
+		"image.png":                  "png",
+		"big.txt":                    "0123456789",
+		".hidden/secret.md":          "secret",
+		"node_modules/pkg/README.md": "pkg",
+		"docs/guide.md":              "guide",
+		"docs/draft.md":              "draft",
+		"docs/.indexignore":          "draft.md\n",
+		"build/out.txt":              "out",
+		"src/keep.md":                "keep",
+		"src/skip.md":                "skip",
+		".indexignore":               "build/\nsrc/*.md\n!src/keep.md\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	external := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(external, "shared.md"), []byte("shared"), 0644))
+	require.NoError(t, os.Symlink(external, filepath.Join(root, "linked")))
+
+	// a link back into the tree must not loop
+	require.NoError(t, os.Symlink(root, filepath.Join(external, "loop")))
+
+	walk := func(files config.Files) []string {
+		var result []string
+
+		f := newFilter(root, files)
+
+		err := f.walk(context.Background(), root, func(path string, e fs.DirEntry, err error) error {
+			require.NoError(t, err)
+
+			result = append(result, f.rel(path))
+			return nil
+		})
+
+		require.NoError(t, err)
+
+		return result
+	}
+
+	require.ElementsMatch(t, []string{
+		"README.md",
+		"notes.txt",
+		"big.txt",
+		"docs/guide.md",
+		"src/keep.md",
+	}, walk(config.Files{
+		Exclude: []string{"node_modules"},
+		MaxSize: 1 << 20,
+	}))
+
+	require.ElementsMatch(t, []string{
+		"README.md",
+		"notes.txt",
+		"docs/guide.md",
+		"linked/shared.md",
+		"src/keep.md",
+		".hidden/secret.md",
+		"node_modules/pkg/README.md",
+	}, walk(config.Files{
+		Hidden:   true,
+		Symlinks: true,
+		MaxSize:  8,
+	}))
+
+	require.ElementsMatch(t, []string{
+		"docs/guide.md",
+	}, walk(config.Files{
+		Extensions: []string{".md"},
+		Include:    []string{"docs/"},
+	}))
+}
+
+func TestFilterAllowed(t *testing.T) {
+	root := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".indexignore"), []byte("vendor/\n"), 0644))
+
+	f := newFilter(root, config.Files{})
+
+	require.True(t, f.allowed(filepath.Join(root, "docs", "deleted.md")))
+	require.True(t, f.allowed(filepath.Join(root, "docs", "page.html")))
+	require.True(t, f.allowed(filepath.Join(root, "data", "config.json")))
+	require.False(t, f.allowed(filepath.Join(root, "vendor", "lib", "README.md")))
+	require.False(t, f.allowed(filepath.Join(root, ".cache", "ab", "content.txt")))
+	require.False(t, f.allowed(filepath.Join(root, "logo.png")))
+}
@@ -0,0 +1,117 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrianliechti/wingman-index/pkg/config"
+	"github.com/adrianliechti/wingman-index/pkg/indexer"
+)
+
+var (
+	_ indexer.Source  = &Source{}
+	_ indexer.Watcher = &Source{}
+)
+
+// Source indexes the files of a local directory
+type Source struct {
+	root  string
+	files config.Files
+}
+
+func New(root string, files config.Files) (*Source, error) {
+	root, err := filepath.Abs(root)
+
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(root)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return nil, errors.New("not a directory: " + root)
+	}
+
+	s := &Source{
+		root:  root,
+		files: files,
+	}
+
+	return s, nil
+}
+
+func (s *Source) Items(ctx context.Context) iter.Seq2[indexer.Item, error] {
+	return func(yield func(indexer.Item, error) bool) {
+		f := newFilter(s.root, s.files)
+
+		f.walk(ctx, s.root, func(path string, e fs.DirEntry, err error) error {
+			var item indexer.Item
+
+			// errors without an entry concern whole directories, which leaves
+			// the listing incomplete and is reported without a path
+			if e != nil {
+				item.Path = "/" + f.rel(path)
+			}
+
+			if err == nil {
+				var info fs.FileInfo
+
+				if info, err = e.Info(); err == nil {
+					item.Size = info.Size()
+					item.Time = info.ModTime()
+				}
+			}
+
+			if !yield(item, err) {
+				return filepath.SkipAll
+			}
+
+			return nil
+		})
+	}
+}
+
+func (s *Source) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	return os.Open(s.path(path))
+}
+
+func (s *Source) Stat(ctx context.Context, path string) (*indexer.Item, error) {
+	f := newFilter(s.root, s.files)
+
+	p := s.path(path)
+
+	if !f.allowed(p) {
+		return nil, fmt.Errorf("%s: %w", path, fs.ErrNotExist)
+	}
+
+	info, err := os.Stat(p)
+
+	if err != nil {
+		return nil, err
+	}
+
+	item := &indexer.Item{
+		Path: path,
+
+		Size: info.Size(),
+		Time: info.ModTime(),
+	}
+
+	return item, nil
+}
+
+// path converts an item path to a file path below the root
+func (s *Source) path(path string) string {
+	return filepath.Join(s.root, filepath.FromSlash(strings.TrimPrefix(path, "/")))
+}
@@ -0,0 +1,56 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrianliechti/wingman-index/pkg/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSource(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "docs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs", "guide.md"), []byte("# Guide"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "logo.png"), []byte("\x89PNG"), 0644))
+
+	s, err := New(root, config.Files{})
+	require.NoError(t, err)
+
+	var paths []string
+
+	for item, err := range s.Items(ctx) {
+		require.NoError(t, err)
+		require.Equal(t, int64(7), item.Size)
+
+		paths = append(paths, item.Path)
+	}
+
+	require.Equal(t, []string{"/docs/guide.md"}, paths)
+
+	r, err := s.Open(ctx, "/docs/guide.md")
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "# Guide", string(data))
+
+	item, err := s.Stat(ctx, "/docs/guide.md")
+	require.NoError(t, err)
+	require.Equal(t, "/docs/guide.md", item.Path)
+
+	_, err = s.Stat(ctx, "/docs/missing.md")
+	require.True(t, errors.Is(err, fs.ErrNotExist))
+
+	_, err = s.Stat(ctx, "/logo.png")
+	require.True(t, errors.Is(err, fs.ErrNotExist))
+}
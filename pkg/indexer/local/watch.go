@@ -0,0 +1,148 @@
+package local
+
+import (
+	"context"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/adrianliechti/wingman-index/pkg/indexer"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounce is the quiet period after the last event before changes are reported
+const debounce = 2 * time.Second
+
+// Watch reports changed and deleted files and their sidecars below the root. Directories created
+// later are watched as well; directories renamed into the tree are only picked
+// up by the next full run.
+func (s *Source) Watch(ctx context.Context, fn func(paths []string)) error {
+	watcher, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return err
+	}
+
+	defer watcher.Close()
+
+	if err := watchDir(watcher, newFilter(s.root, s.files), s.root); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	pending := map[string]bool{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			// re-read .indexignore files and rules for every event
+			f := newFilter(s.root, s.files)
+
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					rel := f.rel(event.Name)
+
+					if f.excludedDir(rel) {
+						continue
+					}
+
+					if err := watchDir(watcher, f, event.Name); err != nil {
+						log.Printf("Error watching directory: %v\n", err)
+					}
+
+					// files may have been created before the directory was watched
+					f.walk(ctx, event.Name, func(path string, e fs.DirEntry, err error) error {
+						if err == nil {
+							pending["/"+f.rel(path)] = true
+						}
+
+						return nil
+					})
+
+					timer.Reset(debounce)
+					continue
+				}
+			}
+
+			// a watched directory moved out of the tree or removed takes its
+			// files along
+			if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				if slices.Contains(watcher.WatchList(), event.Name) {
+					watcher.Remove(event.Name)
+
+					pending["/"+f.rel(event.Name)] = true
+					timer.Reset(debounce)
+					continue
+				}
+			}
+
+			// sidecars are no items themselves but change the metadata of others
+			if indexer.IsSidecar(event.Name) {
+				if rel := f.rel(event.Name); !strings.HasPrefix(rel, "../") && !f.excludedDir(path.Dir(rel)) {
+					pending["/"+rel] = true
+					timer.Reset(debounce)
+				}
+
+				continue
+			}
+
+			if !f.allowed(event.Name) {
+				continue
+			}
+
+			pending["/"+f.rel(event.Name)] = true
+			timer.Reset(debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+
+			log.Printf("Error watching directory: %v\n", err)
+
+		case <-timer.C:
+			paths := slices.Sorted(maps.Keys(pending))
+			clear(pending)
+
+			fn(paths)
+		}
+	}
+}
+
+func watchDir(watcher *fsnotify.Watcher, f *filter, root string) error {
+	return filepath.WalkDir(root, func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if !e.IsDir() {
+			return nil
+		}
+
+		if path != root && f.excluded(f.rel(path), true) {
+			return filepath.SkipDir
+		}
+
+		return watcher.Add(path)
+	})
+}
@@ -0,0 +1,48 @@
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adrianliechti/wingman-index/pkg/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchSidecars(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	root := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "docs"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".drafts"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs", "guide.md"), []byte("# Guide"), 0644))
+
+	s, err := New(root, config.Files{})
+	require.NoError(t, err)
+
+	changes := make(chan []string, 1)
+
+	go s.Watch(ctx, func(paths []string) {
+		changes <- paths
+	})
+
+	// give the watcher time to add the directories
+	time.Sleep(200 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs", "guide.md.meta.json"), []byte(`{"team": "docs"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs", "logo.png"), []byte("\x89PNG"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".drafts", "draft.md.meta.json"), []byte(`{}`), 0644))
+
+	select {
+	case paths := <-changes:
+		require.Equal(t, []string{"/docs/guide.md.meta.json"}, paths)
+
+	case <-time.After(10 * time.Second):
+		t.Fatal("no changes reported")
+	}
+}
@@ -0,0 +1,230 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+	"github.com/adrianliechti/wingman-index/pkg/to"
+)
+
+// embeddingModel describes the vectors produced by the configured embedder
+type embeddingModel struct {
+	Name      string
+	Dimension int
+}
+
+// mismatch describes how the vectors in an index differ from the embedder
+type mismatch struct {
+	Reason string
+
+	// Dimension is set if the vector dimension changed, which requires the
+	// namespace to be recreated
+	Dimension bool
+}
+
+func (idx *Indexer) probeEmbedder(ctx context.Context) (*embeddingModel, error) {
+	if idx.Embedder == nil {
+		return &embeddingModel{}, nil
+	}
+
+	embedding, err := idx.Embedder.Embed(ctx, []string{"init"})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(embedding.Embeddings) == 0 {
+		return nil, errors.New("embedder returned no embeddings")
+	}
+
+	return &embeddingModel{
+		Name:      embedding.Model,
+		Dimension: len(embedding.Embeddings[0]),
+	}, nil
+}
+
+// checkIndex compares the vectors stored in target against the embedder
+func checkIndex(ctx context.Context, target index.Provider, model *embeddingModel) (*mismatch, error) {
+	if s, ok := target.(index.StatsProvider); ok {
+		stats, err := s.Stats(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if stats.Documents == 0 {
+			return nil, nil
+		}
+
+		if stats.Dimension > 0 && model.Dimension > 0 && stats.Dimension != model.Dimension {
+			return &mismatch{
+				Reason:    fmt.Sprintf("dimension %d -> %d", stats.Dimension, model.Dimension),
+				Dimension: true,
+			}, nil
+		}
+
+		if stats.Model != "" && model.Name != "" && stats.Model != model.Name {
+			return &mismatch{
+				Reason: fmt.Sprintf("model %q -> %q", stats.Model, model.Name),
+			}, nil
+		}
+	}
+
+	page, err := target.List(ctx, &index.ListOptions{
+		Limit: to.Ptr(1),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(page.Items) == 0 || model.Name == "" {
+		return nil, nil
+	}
+
+	if name := page.Items[0].Metadata.String("model"); name != "" && name != model.Name {
+		return &mismatch{
+			Reason: fmt.Sprintf("model %q -> %q", name, model.Name),
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// migrate re-embeds and re-indexes all documents of a namespace with the
+// current embedder. Backends with aliases are rebuilt blue/green, so queries
+// keep hitting the previous documents until the cutover. Others are updated
+// in place, recreating the namespace first if the vector dimension changed.
+func (idx *Indexer) migrate(ctx context.Context, namespace string, model *embeddingModel, m *mismatch) error {
+	log.Printf("embedding model of %s changed (%s), re-embedding all documents", namespace, m.Reason)
+
+	manager, ok := idx.Index.(index.NamespaceManager)
+
+	if c, capable := idx.Index.(index.CapabilitiesProvider); ok && capable && c.Capabilities().Aliases {
+		return idx.rebuild(ctx, namespace, model)
+	}
+
+	if m.Dimension {
+		if !ok {
+			return fmt.Errorf("index cannot be migrated: %s", m.Reason)
+		}
+
+		log.Printf("Warning: index has no aliases, recreating namespace %s with dimension %d in place; it is empty until all documents are re-embedded", namespace, model.Dimension)
+
+		if err := manager.DropNamespace(ctx, namespace); err != nil {
+			return err
+		}
+
+		if err := manager.CreateNamespace(ctx, namespace, &index.NamespaceOptions{Dimension: model.Dimension}); err != nil {
+			return err
+		}
+	}
+
+	target, err := idx.target(namespace)
+
+	if err != nil {
+		return err
+	}
+
+	report, err := idx.indexSources(ctx, namespace, namespace, target, model, true)
+
+	if report != nil {
+		log.Printf("re-embedded %d files (%d documents) with %s", report.Files, report.Documents, model)
+	}
+
+	return err
+}
+
+func (m *embeddingModel) String() string {
+	if m.Name == "" {
+		return fmt.Sprintf("unnamed model (dimension %d)", m.Dimension)
+	}
+
+	return fmt.Sprintf("%s (dimension %d)", m.Name, m.Dimension)
+}
+
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// embeddingsFile returns the name of the cache file holding the embeddings of
+// a document for the given model and segmenter settings
+func embeddingsFile(model, segmentation string) string {
+	name := "embeddings"
+
+	if model != "" {
+		name += "-" + unsafeChars.ReplaceAllString(model, "_")
+	}
+
+	if segmentation != "" {
+		name += "+" + unsafeChars.ReplaceAllString(segmentation, "_")
+	}
+
+	return name + ".json"
+}
+
+// importCache moves the cache directory of content written by earlier
+// versions into the state: the extracted text and the embeddings of the
+// current model and segmenter. The directory is removed afterwards.
+func (p *pipeline) importCache(key string) error {
+	dir := filepath.Join(p.CacheDir, key[0:2], key[2:4], key)
+
+	if _, err := os.Stat(dir); err != nil {
+		return nil
+	}
+
+	extracted, err := p.state.has(contentBucket, key)
+
+	if err != nil {
+		return err
+	}
+
+	if exists(dir, "content.txt") && !extracted {
+		text, err := readText(dir, "content.txt")
+
+		if err != nil {
+			return err
+		}
+
+		if err := p.state.put(contentBucket, key, text); err != nil {
+			return err
+		}
+	}
+
+	seg := segmentation(p.segmenter)
+
+	names := []string{embeddingsFile(p.model.Name, seg)}
+
+	// embeddings cached before the cache was keyed by model
+	if seg == "" {
+		names = append(names, "embeddings.json")
+	}
+
+	for _, name := range names {
+		embedded, err := p.state.has(embeddingsBucket, p.embeddingsKey(key))
+
+		if err != nil {
+			return err
+		}
+
+		if embedded || !exists(dir, name) {
+			continue
+		}
+
+		var embeddings Embeddings
+
+		if err := readJSON(dir, name, &embeddings); err != nil || embeddings.Model != p.model.Name || embeddings.Dimension() != p.model.Dimension {
+			continue
+		}
+
+		if err := p.state.put(embeddingsBucket, p.embeddingsKey(key), embeddings); err != nil {
+			return err
+		}
+	}
+
+	return removeEntry(dir)
+}
@@ -0,0 +1,152 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/adrianliechti/wingman-index/pkg/config"
+	"github.com/adrianliechti/wingman-index/pkg/index"
+	"github.com/adrianliechti/wingman-index/pkg/index/memory"
+	"github.com/adrianliechti/wingman-index/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckIndex(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	m, err := checkIndex(context.Context, c, &embeddingModel{Name: "new", Dimension: 10})
+	require.NoError(t, err)
+	require.Nil(t, m)
+
+	err = c.Index(context.Context, index.Document{
+		ID:       "1",
+		Content:  "chunk",
+		Metadata: index.Metadata{"model": "old"},
+	})
+	require.NoError(t, err)
+
+	m, err = checkIndex(context.Context, c, &embeddingModel{Name: "old", Dimension: 10})
+	require.NoError(t, err)
+	require.Nil(t, m)
+
+	m, err = checkIndex(context.Context, c, &embeddingModel{Name: "new", Dimension: 10})
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	require.False(t, m.Dimension)
+
+	m, err = checkIndex(context.Context, c, &embeddingModel{Name: "old", Dimension: 5})
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	require.True(t, m.Dimension)
+}
+
+func TestEmbeddingsFile(t *testing.T) {
+	require.Equal(t, "embeddings.json", embeddingsFile("", ""))
+	require.Equal(t, "embeddings-text-embedding-3-small.json", embeddingsFile("text-embedding-3-small", ""))
+	require.Equal(t, "embeddings-nomic-embed-text_latest.json", embeddingsFile("nomic-embed-text:latest", ""))
+	require.Equal(t, "embeddings-nomic-embed-text_latest+text-2000-200-chars.json", embeddingsFile("nomic-embed-text:latest", "text-2000-200-chars"))
+}
+
+func TestMigrateRecords(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := openState(dir)
+	require.NoError(t, err)
+
+	// records were keyed by namespace and path only
+	err = s.put(documentsBucket, "default\x00/guide.md", record{Source: "docs", Revision: "1"})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Close())
+
+	s, err = openState(dir)
+	require.NoError(t, err)
+
+	defer s.Close()
+
+	records, err := s.records("default")
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]map[string]record{
+		"docs": {"/guide.md": {Source: "docs", Revision: "1"}},
+	}, records)
+}
+
+// unaliased hides the alias support of a provider
+type unaliased struct {
+	*memory.Provider
+}
+
+func (c *unaliased) Capabilities() index.Capabilities {
+	caps := c.Provider.Capabilities()
+	caps.Aliases = false
+
+	return caps
+}
+
+func TestMigrateDimension(t *testing.T) {
+	for _, aliases := range []bool{true, false} {
+		context := test.NewContext()
+
+		m, err := memory.New(memory.WithEmbedder(context.Embedder))
+		require.NoError(t, err)
+
+		var c index.Provider = m
+
+		if !aliases {
+			c = &unaliased{m}
+		}
+
+		// a plain namespace holding vectors of another dimension
+		require.NoError(t, m.CreateNamespace(context.Context, "default", nil))
+
+		err = m.Index(context.Context, index.Document{
+			ID:        "legacy",
+			Content:   "chunk",
+			Embedding: make([]float32, 5),
+		})
+		require.NoError(t, err)
+
+		idx, err := New(&config.Config{
+			Namespace: "default",
+
+			Index:    c,
+			Embedder: context.Embedder,
+
+			CacheDir: t.TempDir(),
+		})
+		require.NoError(t, err)
+
+		src := &testSource{
+			items: map[string]string{
+				"/guide.md": "# Guide\n\nHello world.",
+			},
+		}
+
+		require.NoError(t, idx.AddSource(SourceConfig{Name: "docs", Source: src}))
+		require.NoError(t, idx.Sync(context.Context))
+
+		documents := listDocuments(t, m)
+		require.Len(t, documents, 2)
+
+		for _, d := range documents {
+			require.NotEqual(t, "legacy", d.ID)
+		}
+
+		namespaces, err := m.Namespaces(context.Context)
+		require.NoError(t, err)
+		require.Len(t, namespaces, 1)
+
+		// the namespace is only replaced by an alias where supported
+		if aliases {
+			require.Equal(t, []string{"default"}, namespaces[0].Aliases)
+		} else {
+			require.Equal(t, index.Namespace{Name: "default"}, namespaces[0])
+		}
+
+		idx.Close()
+	}
+}
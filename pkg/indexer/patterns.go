@@ -0,0 +1,200 @@
+package indexer
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile is the name of the .gitignore-style files excluding paths from indexing
+const IgnoreFile = ".indexignore"
+
+// pattern is a single compiled .gitignore-style pattern
+type pattern struct {
+	re *regexp.Regexp
+
+	negate  bool
+	dirOnly bool
+}
+
+// Patterns is a list of .gitignore-style patterns relative to a base directory
+type Patterns struct {
+	base string
+
+	patterns []*pattern
+}
+
+// compilePattern parses a .gitignore line; it returns nil for blank lines and
+// comments
+func compilePattern(line string) *pattern {
+	line = strings.TrimRight(line, " \t\r")
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	p := &pattern{}
+
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	}
+
+	line = strings.TrimPrefix(line, `\`)
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if line == "" {
+		return nil
+	}
+
+	// patterns without an inner slash match at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+
+	if !anchored {
+		expr = "(.*/)?" + expr
+	}
+
+	p.re = regexp.MustCompile("^" + expr + "$")
+
+	return p
+}
+
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+
+		switch c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**/") {
+				sb.WriteString("(.*/)?")
+				i += 2
+			} else if strings.HasPrefix(glob[i:], "**") {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+
+		case '?':
+			sb.WriteString("[^/]")
+
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+
+			class := glob[i+1 : i+1+end]
+
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return sb.String()
+}
+
+// CompilePatterns compiles .gitignore lines relative to base, a slash
+// separated directory below the root ("" for the root itself)
+func CompilePatterns(base string, lines []string) *Patterns {
+	l := &Patterns{
+		base: base,
+	}
+
+	for _, line := range lines {
+		if p := compilePattern(line); p != nil {
+			l.patterns = append(l.patterns, p)
+		}
+	}
+
+	return l
+}
+
+// ReadPatterns reads the .gitignore-style file name
+func ReadPatterns(base, name string) (*Patterns, error) {
+	f, err := os.Open(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var lines []string
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return CompilePatterns(base, lines), nil
+}
+
+// Match reports whether rel (slash separated, relative to the root) matches
+// the list. The last matching pattern wins; ok is false if none matched.
+func (l *Patterns) Match(rel string, dir bool) (matched, ok bool) {
+	if l.base != "" {
+		if !strings.HasPrefix(rel, l.base+"/") {
+			return false, false
+		}
+
+		rel = strings.TrimPrefix(rel, l.base+"/")
+	}
+
+	for _, p := range l.patterns {
+		if p.dirOnly && !dir {
+			continue
+		}
+
+		if p.re.MatchString(rel) {
+			matched, ok = !p.negate, true
+		}
+	}
+
+	return matched, ok
+}
+
+// MatchAny reports whether rel or one of its parent directories matches
+func (l *Patterns) MatchAny(rel string) bool {
+	dir := false
+
+	for p := rel; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+		if matched, ok := l.Match(p, dir); ok && matched {
+			return true
+		}
+
+		dir = true
+	}
+
+	return false
+}
+
+// Empty reports whether the list contains no patterns
+func (l *Patterns) Empty() bool {
+	return l == nil || len(l.patterns) == 0
+}
@@ -0,0 +1,38 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		dir     bool
+		match   bool
+	}{
+		{"node_modules", "node_modules", true, true},
+		{"node_modules", "web/node_modules", true, true},
+		{"build/", "build", false, false},
+		{"build/", "build", true, true},
+		{"/docs", "docs", true, true},
+		{"/docs", "web/docs", true, false},
+		{"*.log", "a/b/c.log", false, true},
+		{"docs/*.md", "docs/a.md", false, true},
+		{"docs/*.md", "docs/sub/a.md", false, false},
+		{"docs/**/*.md", "docs/sub/a.md", false, true},
+		{"docs/**/*.md", "docs/a.md", false, true},
+		{"**/tmp", "a/b/tmp", true, true},
+		{"file-[0-9].txt", "file-1.txt", false, true},
+		{"file-[!0-9].txt", "file-1.txt", false, false},
+	}
+
+	for _, tt := range tests {
+		l := CompilePatterns("", []string{tt.pattern})
+
+		matched, _ := l.Match(tt.path, tt.dir)
+		require.Equal(t, tt.match, matched, "%s ~ %s", tt.pattern, tt.path)
+	}
+}
@@ -0,0 +1,641 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+
+	"github.com/google/uuid"
+)
+
+// pipeline indexes the items of a source in stages (discover → extract →
+// segment → embed → upsert) connected by bounded channels, each stage with its
+// own workers
+type pipeline struct {
+	*Indexer
+
+	src    *source
+	target index.Provider
+
+	// namespace is the namespace the records of pushed items are kept under
+	namespace string
+
+	model *embeddingModel
+	force bool
+
+	mu  sync.Mutex
+	err error
+
+	// failed records the paths of items that could not be indexed
+	failed map[string]bool
+
+	// incomplete is set if the source could not be enumerated completely
+	incomplete bool
+
+	// items and revisions record the completed items by document path
+	items     map[string]Item
+	revisions map[string]string
+
+	// sidecars caches the metadata files of directories during the run
+	sidecarsMu sync.Mutex
+	sidecars   map[string]index.Metadata
+}
+
+// job carries a single item through the pipeline
+type job struct {
+	item Item
+
+	metadata Metadata
+
+	// fields are read from front matter and sidecar metadata files
+	fields index.Metadata
+
+	// key identifies the content of the item in the state
+	key    string
+	unlock func()
+
+	segments []Segment
+
+	// embedded is set if the embeddings were computed by this run
+	embedded bool
+
+	count int
+}
+
+func (j *job) release() {
+	if j.unlock != nil {
+		j.unlock()
+		j.unlock = nil
+	}
+}
+
+// run processes the given jobs and returns them once completed
+func (p *pipeline) run(ctx context.Context, jobs <-chan *job) <-chan *job {
+	jobs = p.stage(ctx, p.ExtractConcurrency, jobs, p.extract)
+	jobs = p.stage(ctx, p.SegmentConcurrency, jobs, p.segment)
+	jobs = p.stage(ctx, p.EmbedConcurrency, jobs, p.embed)
+	jobs = p.stage(ctx, p.UpsertConcurrency, jobs, p.upsert)
+
+	return jobs
+}
+
+func (p *pipeline) fail(j *job, err error) {
+	j.release()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if j.item.Path == "" {
+		p.incomplete = true
+	} else {
+		if p.failed == nil {
+			p.failed = make(map[string]bool)
+		}
+
+		p.failed[p.src.path(j.item.Path)] = true
+
+		err = fmt.Errorf("%s: %w", j.item.Path, err)
+	}
+
+	p.err = errors.Join(p.err, err)
+}
+
+// stage runs fn on each job with the given number of workers. Failed jobs are
+// recorded and dropped; the output channel is closed once the input is drained.
+func (p *pipeline) stage(ctx context.Context, workers int, in <-chan *job, fn func(context.Context, *job) error) <-chan *job {
+	workers = max(1, workers)
+
+	out := make(chan *job, workers)
+
+	var wg sync.WaitGroup
+
+	for range workers {
+		wg.Go(func() {
+			for j := range in {
+				if err := ctx.Err(); err != nil {
+					j.release()
+					continue
+				}
+
+				if err := fn(ctx, j); err != nil {
+					p.fail(j, err)
+					continue
+				}
+
+				select {
+				case out <- j:
+				case <-ctx.Done():
+					j.release()
+				}
+			}
+		})
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// feed sends the given jobs
+func feed(ctx context.Context, jobs []*job) <-chan *job {
+	out := make(chan *job)
+
+	go func() {
+		defer close(out)
+
+		for _, j := range jobs {
+			select {
+			case out <- j:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// discover enumerates the items of the source
+func (p *pipeline) discover(ctx context.Context) <-chan *job {
+	out := make(chan *job)
+
+	go func() {
+		defer close(out)
+
+		for item, err := range p.src.Source.Items(ctx) {
+			if err != nil {
+				p.fail(&job{item: item}, err)
+				continue
+			}
+
+			if IsSidecar(item.Path) {
+				continue
+			}
+
+			select {
+			case out <- &job{item: item}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// extract identifies the content of an item and extracts its text. Items
+// without a revision are hashed, unless their size and modification time did
+// not change since they were last hashed.
+func (p *pipeline) extract(ctx context.Context, j *job) error {
+	var data []byte
+
+	read := func() error {
+		if data != nil {
+			return nil
+		}
+
+		r, err := p.src.Source.Open(ctx, j.item.Path)
+
+		if err != nil {
+			return err
+		}
+
+		defer r.Close()
+
+		data, err = io.ReadAll(r)
+		return err
+	}
+
+	revision := j.item.Revision
+
+	if revision == "" {
+		key := p.src.Name + ":" + j.item.Path
+
+		var ok bool
+
+		if revision, ok = p.revision(key, j.item); !ok {
+			if err := read(); err != nil {
+				return err
+			}
+
+			md5_hash := md5.Sum(data)
+			revision = hex.EncodeToString(md5_hash[:])
+
+			err := p.state.put(filesBucket, key, fileState{
+				Size: j.item.Size,
+				Time: j.item.Time,
+
+				Revision: revision,
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	j.key = cacheKey(revision)
+
+	// items with identical content share their extraction and embeddings
+	j.unlock = p.lock(j.key)
+
+	p.refs.add(j.key)
+
+	if err := p.importCache(j.key); err != nil {
+		return err
+	}
+
+	name := path.Base(j.item.Path)
+	title := strings.TrimSuffix(name, path.Ext(name))
+
+	// directory sidecars apply first, then front matter and the file sidecar
+	fields, err := p.directoryFields(ctx, j.item.Path)
+
+	if err != nil {
+		return err
+	}
+
+	var markdownTitle string
+
+	if isMarkdown(name) {
+		if err := read(); err != nil {
+			return err
+		}
+
+		matter, body, err := parseFrontMatter(normalizeText(data))
+
+		if err != nil {
+			log.Printf("ignoring front matter of %s: %v", j.item.Path, err)
+		}
+
+		for k, v := range matter {
+			fields[k] = v
+		}
+
+		markdownTitle = extractMarkdownTitle([]byte(body))
+	}
+
+	sidecar, err := p.fileSidecar(ctx, j.item.Path)
+
+	if err != nil {
+		return err
+	}
+
+	for k, v := range sidecar {
+		fields[k] = v
+	}
+
+	if len(fields) > 0 {
+		j.fields = fields
+	}
+
+	if s := fields.String("title"); s != "" {
+		title = s
+	} else if j.item.Title != "" {
+		title = j.item.Title
+	} else if markdownTitle != "" {
+		title = markdownTitle
+	}
+
+	j.metadata = Metadata{
+		Name: name,
+		Path: p.src.path(j.item.Path),
+
+		Title:    title,
+		Revision: revision,
+
+		Size: j.item.Size,
+		Time: j.item.Time,
+	}
+
+	extracted, err := p.state.has(contentBucket, j.key)
+
+	if err != nil {
+		return err
+	}
+
+	if !extracted {
+		if err := read(); err != nil {
+			return err
+		}
+
+		text, err := p.extractor.Extract(ctx, j.metadata.Name, data)
+
+		if err != nil {
+			return err
+		}
+
+		if err := p.state.put(contentBucket, j.key, text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cacheKey returns the content key of a revision. Hex digests
+// such as md5 sums or git blob hashes are used as is.
+func cacheKey(revision string) string {
+	if len(revision) >= 32 && isHex(revision) {
+		return strings.ToLower(revision)
+	}
+
+	hash := md5.Sum([]byte(revision))
+	return hex.EncodeToString(hash[:])
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// segment splits the extracted text unless embeddings are already stored
+func (p *pipeline) segment(ctx context.Context, j *job) error {
+	if p.Embedder == nil {
+		return nil
+	}
+
+	if embedded, err := p.state.has(embeddingsBucket, p.embeddingsKey(j.key)); embedded || err != nil {
+		return err
+	}
+
+	var text string
+
+	if _, err := p.state.get(contentBucket, j.key, &text); err != nil {
+		return err
+	}
+
+	segments, err := p.segmenter.Segment(ctx, j.metadata.Name, text)
+
+	if err != nil {
+		return err
+	}
+
+	j.segments = append([]Segment{{Text: j.metadata.Title}}, segments...)
+
+	return nil
+}
+
+// embeddingsKey identifies the embeddings of content for the current model
+// and segmenter
+func (p *pipeline) embeddingsKey(key string) string {
+	return embeddingsKey(key, p.model.Name, segmentation(p.segmenter))
+}
+
+// embed embeds the segments of a job in batches and caches the result
+func (p *pipeline) embed(ctx context.Context, j *job) error {
+	if j.segments == nil {
+		return nil
+	}
+
+	embeddings := Embeddings{}
+
+	for batch := range slices.Chunk(j.segments, max(1, p.BatchSize)) {
+		var texts []string
+
+		for _, segment := range batch {
+			texts = append(texts, segment.Text)
+		}
+
+		embedding, err := p.Embedder.Embed(ctx, texts)
+
+		if err != nil {
+			return err
+		}
+
+		if len(embedding.Embeddings) != len(batch) {
+			return fmt.Errorf("embedder returned %d embeddings for %d texts", len(embedding.Embeddings), len(batch))
+		}
+
+		if embeddings.Model == "" {
+			embeddings.Model = embedding.Model
+		}
+
+		for i, segment := range batch {
+			segment.Embedding = embedding.Embeddings[i]
+			embeddings.Segments = append(embeddings.Segments, segment)
+		}
+	}
+
+	j.segments = nil
+	j.embedded = true
+
+	return p.state.put(embeddingsBucket, p.embeddingsKey(j.key), embeddings)
+}
+
+// documentIDs is the UUID namespace of document IDs
+var documentIDs = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/adrianliechti/wingman-index"))
+
+// documentID derives a stable ID for a segment of a file of a source, so
+// pushing the same revision again overwrites its documents instead of adding
+// new ones
+func documentID(source, path, revision string, i int) string {
+	return uuid.NewSHA1(documentIDs, []byte(fmt.Sprintf("%s\x00%s\x00%s\x00%d", source, path, strings.ToLower(revision), i))).String()
+}
+
+// reservedFields are set by the indexer and not overridden by front matter
+// or sidecars
+var reservedFields = []string{
+	"source",
+	"filename",
+	"filepath",
+	"index",
+	"revision",
+	"size",
+	"modified",
+	"model",
+	"language",
+}
+
+// changed reports whether metadata differs from the values recorded with the
+// documents of an item
+func changed(recorded map[string]any, metadata index.Metadata) bool {
+	if len(recorded) == 0 && len(metadata) == 0 {
+		return false
+	}
+
+	before, _ := json.Marshal(recorded)
+	after, _ := json.Marshal(metadata.Values())
+
+	return !bytes.Equal(before, after)
+}
+
+// upsert replaces the documents of the item in the target index if needed
+func (p *pipeline) upsert(ctx context.Context, j *job) error {
+	defer j.release()
+
+	if p.target == nil {
+		return nil
+	}
+
+	key := documentKey(p.namespace, p.src.Name, j.metadata.Path)
+
+	var previous record
+
+	found, err := p.state.get(documentsBucket, key, &previous)
+
+	if err != nil {
+		return err
+	}
+
+	// new embeddings, e.g. after segmenter settings changed, are always pushed
+	push := p.force || j.embedded || !found || previous.Revision != j.metadata.Revision || previous.Segmentation != segmentation(p.segmenter)
+
+	if !push && previous.Model != p.model.Name {
+		log.Printf("re-embedding %s: model %q -> %q", j.metadata.Path, previous.Model, p.model.Name)
+		push = true
+	}
+
+	// sources and sidecars change the metadata without changing the revision
+	if !push {
+		push = changed(previous.Metadata, j.item.Metadata) || changed(previous.Fields, j.fields)
+	}
+
+	if !push {
+		return nil
+	}
+
+	var embeddings Embeddings
+
+	if _, err := p.state.get(embeddingsBucket, p.embeddingsKey(j.key), &embeddings); err != nil {
+		return err
+	}
+
+	// documents indexed before their IDs were recorded are replaced as a whole
+	if !found {
+		if err := p.target.DeleteWhere(ctx, map[string]any{"source": p.src.Name, "filepath": j.metadata.Path}); err != nil {
+			return err
+		}
+	}
+
+	var documents []index.Document
+
+	link := j.metadata.Path
+
+	if j.item.Link != "" {
+		link = j.item.Link
+	}
+
+	for i, segment := range embeddings.Segments {
+		document := index.Document{
+			ID: documentID(p.src.Name, j.metadata.Path, j.metadata.Revision, i),
+
+			Title:  j.metadata.Title,
+			Source: fmt.Sprintf("%s#%d", link, i+1),
+
+			Content:   segment.Text,
+			Embedding: segment.Embedding,
+
+			Metadata: index.Metadata{
+				"source": p.src.Name,
+
+				"filename": j.metadata.Name,
+				"filepath": j.metadata.Path,
+
+				"index":    int64(i),
+				"revision": j.metadata.Revision,
+
+				"size":     j.metadata.Size,
+				"modified": j.metadata.Time,
+			},
+		}
+
+		if language := Language(j.metadata.Name); language != "" {
+			document.Metadata["language"] = language
+		}
+
+		for k, v := range p.src.Metadata {
+			document.Metadata[k] = v
+		}
+
+		for k, v := range j.item.Metadata {
+			document.Metadata[k] = v
+		}
+
+		for k, v := range j.fields {
+			if !slices.Contains(reservedFields, k) {
+				document.Metadata[k] = v
+			}
+		}
+
+		for k, v := range segment.Metadata {
+			document.Metadata[k] = v
+		}
+
+		if embeddings.Model != "" {
+			document.Metadata["model"] = embeddings.Model
+		}
+
+		documents = append(documents, document)
+	}
+
+	if err := p.target.Index(ctx, documents...); err != nil {
+		return err
+	}
+
+	ids := map[string]bool{}
+
+	for _, d := range documents {
+		ids[d.ID] = true
+	}
+
+	// remove documents of the previous revision that were not overwritten
+	var stale []string
+
+	for _, id := range previous.Documents {
+		if !ids[id] {
+			stale = append(stale, id)
+		}
+	}
+
+	if len(stale) > 0 {
+		if err := p.target.Delete(ctx, stale...); err != nil {
+			return err
+		}
+	}
+
+	r := record{
+		Source: p.src.Name,
+
+		Revision: j.metadata.Revision,
+
+		Model:        p.model.Name,
+		Segmentation: segmentation(p.segmenter),
+
+		Metadata: j.item.Metadata.Values(),
+		Fields:   j.fields.Values(),
+
+		Indexed: time.Now(),
+	}
+
+	for _, d := range documents {
+		hash := md5.Sum([]byte(d.Content))
+
+		r.Chunks = append(r.Chunks, hex.EncodeToString(hash[:]))
+		r.Documents = append(r.Documents, d.ID)
+	}
+
+	if err := p.state.put(documentsBucket, key, r); err != nil {
+		return err
+	}
+
+	j.count = len(documents)
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrianliechti/wingman-index/pkg/config"
+	"github.com/adrianliechti/wingman-index/pkg/index"
+	"github.com/adrianliechti/wingman-index/pkg/index/memory"
+	"github.com/adrianliechti/wingman-index/test"
+
+	"github.com/adrianliechti/wingman/pkg/provider"
+	"github.com/stretchr/testify/require"
+)
+
+// failingExtractor fails to extract bad.txt and returns other files as is
+type failingExtractor struct{}
+
+func (failingExtractor) Extract(ctx context.Context, name string, data []byte) (string, error) {
+	if name == "bad.txt" {
+		return "", errors.New("cannot extract")
+	}
+
+	return string(data), nil
+}
+
+// blockingEmbedder blocks every call after the first three until ctx is
+// done, once more jobs completed than the output of the pipeline buffers
+type blockingEmbedder struct {
+	*test.MockEmbedder
+
+	once    sync.Once
+	blocked chan struct{}
+
+	calls int
+	mu    sync.Mutex
+}
+
+func (e *blockingEmbedder) Embed(ctx context.Context, texts []string) (*provider.Embedding, error) {
+	e.mu.Lock()
+	e.calls++
+	calls := e.calls
+	e.mu.Unlock()
+
+	if calls <= 3 {
+		return e.MockEmbedder.Embed(ctx, texts)
+	}
+
+	e.once.Do(func() { close(e.blocked) })
+
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func newPipelineIndexer(t *testing.T, embedder index.Embedder, options ...Option) (*Indexer, *memory.Provider) {
+	c, err := memory.New(memory.WithEmbedder(embedder))
+	require.NoError(t, err)
+
+	idx, err := New(&config.Config{
+		Namespace: "default",
+
+		Index:    c,
+		Embedder: embedder,
+
+		BatchSize: 8,
+
+		ExtractConcurrency: 2,
+		SegmentConcurrency: 2,
+		EmbedConcurrency:   2,
+		UpsertConcurrency:  2,
+
+		CacheDir: t.TempDir(),
+	}, options...)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { idx.Close() })
+
+	return idx, c
+}
+
+func TestPipelineFailedItem(t *testing.T) {
+	context := test.NewContext()
+
+	idx, c := newPipelineIndexer(t, context.Embedder, WithExtractor(failingExtractor{}))
+
+	src := &testSource{items: map[string]string{}}
+
+	for i := range 10 {
+		src.items[fmt.Sprintf("/%d.txt", i)] = fmt.Sprintf("Item %d", i)
+	}
+
+	src.items["/bad.txt"] = "Bad item"
+
+	require.NoError(t, idx.AddSource(SourceConfig{Name: "docs", Source: src}))
+
+	err := idx.Sync(context.Context)
+
+	require.ErrorContains(t, err, "/bad.txt: cannot extract")
+
+	documents := listDocuments(t, c)
+	require.Len(t, documents, 20)
+
+	records, err := idx.state.records("default")
+	require.NoError(t, err)
+	require.Len(t, records["docs"], 10)
+	require.NotContains(t, records["docs"], "/bad.txt")
+
+	requireUnlocked(t, idx)
+}
+
+func TestPipelineCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	embedder := &blockingEmbedder{
+		MockEmbedder: test.NewMockEmbedder(),
+
+		blocked: make(chan struct{}),
+	}
+
+	idx, c := newPipelineIndexer(t, embedder)
+
+	src := &testSource{items: map[string]string{}}
+
+	for i := range 50 {
+		src.items[fmt.Sprintf("/%d.txt", i)] = fmt.Sprintf("Item %d", i)
+	}
+
+	require.NoError(t, idx.AddSource(SourceConfig{Name: "docs", Source: src}))
+
+	goroutines := runtime.NumGoroutine()
+
+	p := &pipeline{
+		Indexer: idx,
+
+		src:    idx.sources[0],
+		target: c,
+
+		namespace: "default",
+
+		model: &embeddingModel{},
+	}
+
+	// the output is abandoned once cancelled, so no stage may block on it
+	p.run(ctx, p.discover(ctx))
+
+	<-embedder.blocked
+
+	// wait until the embedded jobs are upserted and fill the output
+	for {
+		records, err := idx.state.records("default")
+		require.NoError(t, err)
+
+		if len(records["docs"]) == 3 {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+
+	// stage workers exit shortly after the last job was drained
+	for deadline := time.Now().Add(5 * time.Second); runtime.NumGoroutine() > goroutines && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.LessOrEqual(t, runtime.NumGoroutine(), goroutines, "pipeline goroutines leaked")
+
+	requireUnlocked(t, idx)
+}
+
+// requireUnlocked checks that no content lock is held
+func requireUnlocked(t *testing.T, idx *Indexer) {
+	idx.locks.Range(func(key, value any) bool {
+		mu := value.(*sync.Mutex)
+
+		require.True(t, mu.TryLock(), "content %s is still locked", key)
+		mu.Unlock()
+
+		return true
+	})
+}
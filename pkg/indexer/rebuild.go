@@ -0,0 +1,236 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+	"github.com/adrianliechti/wingman-index/pkg/to"
+)
+
+// Rebuild re-indexes each namespace into a fresh shadow namespace, validates
+// it and then atomically switches the serving alias to it, dropping the
+// previous namespace. Queries keep hitting the old namespace until the cutover
+// succeeds.
+func (idx *Indexer) Rebuild(ctx context.Context) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	model, err := idx.probeEmbedder(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	var result error
+
+	for _, namespace := range idx.namespaces() {
+		if err := idx.rebuild(ctx, namespace, model); err != nil {
+			result = errors.Join(result, err)
+		}
+	}
+
+	return result
+}
+
+func (idx *Indexer) rebuild(ctx context.Context, alias string, model *embeddingModel) error {
+	m, ok := idx.Index.(index.NamespaceManager)
+
+	if !ok {
+		return errors.New("index does not support namespaces")
+	}
+
+	if c, ok := idx.Index.(index.CapabilitiesProvider); !ok || !c.Capabilities().Aliases {
+		return errors.New("index does not support namespace aliases")
+	}
+
+	namespaces, err := m.Namespaces(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	// plain is set if the serving name is a namespace rather than an alias,
+	// as created by syncs before namespaces were served behind aliases
+	var plain bool
+
+	var previous []string
+
+	for _, ns := range namespaces {
+		if ns.Name == alias {
+			plain = true
+		}
+
+		if slices.Contains(ns.Aliases, alias) {
+			previous = append(previous, ns.Name)
+		}
+	}
+
+	shadow := backingNamespace(alias, namespaces)
+
+	if err := m.CreateNamespace(ctx, shadow, &index.NamespaceOptions{Dimension: model.Dimension}); err != nil {
+		return err
+	}
+
+	target := m.WithNamespace(shadow)
+
+	log.Printf("rebuilding %s into %s with %s", alias, shadow, model)
+
+	// the records of the shadow namespace are kept apart, so a failed rebuild
+	// leaves the records of the serving namespace untouched
+	report, err := idx.indexSources(ctx, alias, shadow, target, model, true)
+
+	if err == nil {
+		err = validate(ctx, target, report)
+	}
+
+	// a plain namespace has to be dropped before its name can become an alias
+	if err == nil && plain {
+		log.Printf("replacing namespace %s by an alias, it is unavailable until the alias is created", alias)
+
+		if err := m.DropNamespace(ctx, alias); err != nil {
+			return errors.Join(err, idx.discard(ctx, m, shadow))
+		}
+
+		if err := m.AliasNamespace(ctx, alias, shadow); err != nil {
+			return fmt.Errorf("namespace %s was dropped, but could not be aliased to %s: %w", alias, shadow, err)
+		}
+	}
+
+	if err == nil && !plain {
+		err = m.AliasNamespace(ctx, alias, shadow)
+	}
+
+	if err != nil {
+		return errors.Join(err, idx.discard(ctx, m, shadow))
+	}
+
+	log.Printf("switched %s to %s (%d files, %d documents)", alias, shadow, report.Files, report.Documents)
+
+	result := idx.state.moveRecords(shadow, alias)
+
+	for _, name := range previous {
+		if err := m.DropNamespace(ctx, name); err != nil {
+			result = errors.Join(result, err)
+			continue
+		}
+
+		log.Printf("dropped namespace %s", name)
+	}
+
+	return result
+}
+
+// discard drops a shadow namespace that was not put into service, along with
+// its records
+func (idx *Indexer) discard(ctx context.Context, m index.NamespaceManager, shadow string) error {
+	return errors.Join(m.DropNamespace(ctx, shadow), idx.state.dropRecords(shadow))
+}
+
+// ensureAlias creates a namespace that does not exist yet as an alias of a
+// backing namespace, so later rebuilds can replace it without downtime
+func (idx *Indexer) ensureAlias(ctx context.Context, alias string, model *embeddingModel) error {
+	m, ok := idx.Index.(index.NamespaceManager)
+
+	if !ok {
+		return nil
+	}
+
+	if c, ok := idx.Index.(index.CapabilitiesProvider); !ok || !c.Capabilities().Aliases {
+		return nil
+	}
+
+	namespaces, err := m.Namespaces(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		if ns.Name == alias || slices.Contains(ns.Aliases, alias) {
+			return nil
+		}
+	}
+
+	name := backingNamespace(alias, namespaces)
+
+	if err := m.CreateNamespace(ctx, name, &index.NamespaceOptions{Dimension: model.Dimension}); err != nil {
+		return err
+	}
+
+	if err := m.AliasNamespace(ctx, alias, name); err != nil {
+		return errors.Join(err, m.DropNamespace(ctx, name))
+	}
+
+	log.Printf("created namespace %s behind alias %s", name, alias)
+
+	return nil
+}
+
+// backingNamespace returns an unused name for a namespace served behind alias
+func backingNamespace(alias string, namespaces []index.Namespace) string {
+	base := alias + "_" + time.Now().UTC().Format("20060102150405")
+
+	name := base
+
+	for i := 2; slices.ContainsFunc(namespaces, func(ns index.Namespace) bool { return ns.Name == name }); i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+
+	return name
+}
+
+// validate checks that a freshly built namespace holds the expected number of
+// documents and answers sample queries before it is put into service
+func validate(ctx context.Context, p index.Provider, report *report) error {
+	if s, ok := p.(index.StatsProvider); ok {
+		var count int64
+
+		// backends may take a moment until written documents are visible
+		for i := range 5 {
+			if i > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(time.Duration(i) * time.Second):
+				}
+			}
+
+			stats, err := s.Stats(ctx)
+
+			if err != nil {
+				return err
+			}
+
+			count = stats.Documents
+
+			if count >= int64(report.Documents) {
+				break
+			}
+		}
+
+		if count < int64(report.Documents) {
+			return fmt.Errorf("validation failed: expected %d documents, found %d", report.Documents, count)
+		}
+	}
+
+	for _, title := range report.Titles {
+		results, err := p.Query(ctx, title, &index.QueryOptions{
+			Limit: to.Ptr(1),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if len(results) == 0 {
+			return fmt.Errorf("validation failed: no results for %q", title)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package indexer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adrianliechti/wingman-index/pkg/config"
+	"github.com/adrianliechti/wingman-index/pkg/index"
+	"github.com/adrianliechti/wingman-index/pkg/index/memory"
+	"github.com/adrianliechti/wingman-index/test"
+
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+// rebuildIndex fails the validation of rebuilt namespaces while failing is set
+type rebuildIndex struct {
+	*memory.Provider
+
+	failing bool
+}
+
+func (c *rebuildIndex) WithNamespace(name string) index.Provider {
+	p := c.Provider.WithNamespace(name)
+
+	if c.failing {
+		return &unanswered{p}
+	}
+
+	return p
+}
+
+// unanswered finds nothing for any query
+type unanswered struct {
+	index.Provider
+}
+
+func (p *unanswered) Query(ctx context.Context, query string, options *index.QueryOptions) ([]index.Result, error) {
+	return nil, nil
+}
+
+func TestRebuild(t *testing.T) {
+	context := test.NewContext()
+
+	m, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	c := &rebuildIndex{Provider: m, failing: true}
+
+	idx, err := New(&config.Config{
+		Namespace: "default",
+
+		Index:    c,
+		Embedder: context.Embedder,
+
+		CacheDir: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	defer idx.Close()
+
+	src := &testSource{
+		items: map[string]string{
+			"/guide.md":  "# Guide\n\nHello world.",
+			"/notes.txt": "Some notes.",
+		},
+	}
+
+	require.NoError(t, idx.AddSource(SourceConfig{Name: "docs", Source: src}))
+
+	// a failed validation leaves neither the namespace nor its records behind
+	require.ErrorContains(t, idx.Rebuild(context.Context), "validation failed")
+
+	namespaces, err := m.Namespaces(context.Context)
+	require.NoError(t, err)
+	require.Empty(t, namespaces)
+
+	require.Empty(t, allRecords(t, idx))
+
+	c.failing = false
+
+	require.NoError(t, idx.Rebuild(context.Context))
+
+	namespaces, err = m.Namespaces(context.Context)
+	require.NoError(t, err)
+	require.Len(t, namespaces, 1)
+	require.Equal(t, []string{"default"}, namespaces[0].Aliases)
+
+	records, err := idx.state.records("default")
+	require.NoError(t, err)
+	require.Len(t, records["docs"], 2)
+	require.Len(t, allRecords(t, idx), 2)
+
+	require.Len(t, listDocuments(t, m), 4)
+}
+
+func TestRebuildPlainNamespace(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	idx, err := New(&config.Config{
+		Namespace: "default",
+
+		Index:    c,
+		Embedder: context.Embedder,
+
+		CacheDir: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	defer idx.Close()
+
+	src := &testSource{
+		items: map[string]string{
+			"/guide.md": "# Guide\n\nHello world.",
+		},
+	}
+
+	// namespaces created before they were served behind aliases
+	require.NoError(t, c.CreateNamespace(context.Context, "default", nil))
+
+	require.NoError(t, idx.AddSource(SourceConfig{Name: "docs", Source: src}))
+	require.NoError(t, idx.Sync(context.Context))
+
+	require.NoError(t, idx.Rebuild(context.Context))
+
+	namespaces, err := c.Namespaces(context.Context)
+	require.NoError(t, err)
+	require.Len(t, namespaces, 1)
+	require.Equal(t, []string{"default"}, namespaces[0].Aliases)
+
+	records, err := idx.state.records("default")
+	require.NoError(t, err)
+	require.Len(t, records["docs"], 1)
+	require.Len(t, allRecords(t, idx), 1)
+
+	require.Len(t, listDocuments(t, c), 2)
+}
+
+func TestSyncAlias(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	idx, err := New(&config.Config{
+		Namespace: "default",
+
+		Index:    c,
+		Embedder: context.Embedder,
+
+		CacheDir: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	defer idx.Close()
+
+	src := &testSource{
+		items: map[string]string{
+			"/guide.md": "# Guide\n\nHello world.",
+		},
+	}
+
+	require.NoError(t, idx.AddSource(SourceConfig{Name: "docs", Source: src}))
+
+	// the first sync serves a new namespace behind an alias
+	require.NoError(t, idx.Sync(context.Context))
+	require.NoError(t, idx.Sync(context.Context))
+
+	namespaces, err := c.Namespaces(context.Context)
+	require.NoError(t, err)
+	require.Len(t, namespaces, 1)
+	require.Equal(t, []string{"default"}, namespaces[0].Aliases)
+
+	require.NoError(t, idx.Rebuild(context.Context))
+
+	rebuilt, err := c.Namespaces(context.Context)
+	require.NoError(t, err)
+	require.Len(t, rebuilt, 1)
+	require.NotEqual(t, namespaces[0].Name, rebuilt[0].Name)
+	require.Equal(t, []string{"default"}, rebuilt[0].Aliases)
+
+	require.Len(t, listDocuments(t, c), 2)
+}
+
+// allRecords returns the keys of the records of all namespaces
+func allRecords(t *testing.T, idx *Indexer) []string {
+	var result []string
+
+	err := idx.state.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(documentsBucket).ForEach(func(k, v []byte) error {
+			result = append(result, string(k))
+			return nil
+		})
+	})
+
+	require.NoError(t, err)
+
+	return result
+}
@@ -0,0 +1,248 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+	"github.com/adrianliechti/wingman-index/pkg/to"
+)
+
+// reconcile compares the documents of a namespace with the state recorded
+// for the items of runs. Documents no item accounts for are deleted; items
+// whose documents are missing, e.g. after the backend lost data, are pushed
+// again.
+func (idx *Indexer) reconcile(ctx context.Context, namespace string, target index.Provider, model *embeddingModel, runs []*pipeline) (*report, error) {
+	report := &report{}
+
+	documents, err := listAll(ctx, target)
+
+	if err != nil {
+		return report, err
+	}
+
+	records, err := idx.state.records(namespace)
+
+	if err != nil {
+		return report, err
+	}
+
+	// without complete listings, missing items cannot be told from deleted ones
+	complete := !slices.ContainsFunc(runs, func(p *pipeline) bool {
+		return p.incomplete
+	})
+
+	present := map[string]bool{}
+
+	var orphans []string
+
+	for _, d := range documents {
+		present[d.ID] = true
+
+		if orphaned(d, runs, records, complete) {
+			orphans = append(orphans, d.ID)
+		}
+	}
+
+	// listings without paging may be truncated, so recorded documents that
+	// were not listed are looked up
+	if c, ok := target.(index.CapabilitiesProvider); !ok || !c.Capabilities().Paging {
+		if err := lookup(ctx, target, records, present); err != nil {
+			return report, err
+		}
+	}
+
+	if len(orphans) > 0 {
+		if err := target.Delete(ctx, orphans...); err != nil {
+			return report, err
+		}
+
+		report.Orphans = len(orphans)
+	}
+
+	var result error
+
+	for _, p := range runs {
+		if !p.incomplete {
+			if err := idx.forget(namespace, p, records[p.src.Name]); err != nil {
+				return report, err
+			}
+		}
+
+		var jobs []*job
+
+		for path := range p.revisions {
+			if p.failed[path] {
+				continue
+			}
+
+			r, ok := records[p.src.Name][path]
+
+			if ok && !slices.ContainsFunc(r.Documents, func(id string) bool { return !present[id] }) {
+				continue
+			}
+
+			jobs = append(jobs, &job{item: p.items[path]})
+		}
+
+		if len(jobs) == 0 {
+			continue
+		}
+
+		retry := &pipeline{
+			Indexer: idx,
+
+			src:    p.src,
+			target: target,
+
+			namespace: namespace,
+
+			model: model,
+			force: true,
+		}
+
+		for j := range retry.run(ctx, feed(ctx, jobs)) {
+			report.Missing++
+			report.Documents += j.count
+		}
+
+		result = errors.Join(result, retry.err)
+	}
+
+	if report.Missing > 0 || report.Orphans > 0 {
+		log.Printf("reconciled %s: pushed %d files with missing documents, deleted %d orphaned documents", namespace, report.Missing, report.Orphans)
+	}
+
+	return report, result
+}
+
+// orphaned reports whether no item accounts for a document: it lacks the
+// metadata of indexed files, belongs to no source, its file no longer exists
+// or changed, or the file was pushed again with other documents
+func orphaned(d index.Document, runs []*pipeline, records map[string]map[string]record, complete bool) bool {
+	path := d.Metadata.String("filepath")
+	revision := d.Metadata.String("revision")
+
+	if path == "" || revision == "" {
+		return true
+	}
+
+	i := slices.IndexFunc(runs, func(p *pipeline) bool {
+		return p.src.owns(d)
+	})
+
+	if i < 0 {
+		return complete
+	}
+
+	p := runs[i]
+
+	if p.incomplete || p.failed[path] {
+		return false
+	}
+
+	if current, ok := p.revisions[path]; !ok || !strings.EqualFold(current, revision) {
+		return true
+	}
+
+	r, ok := records[p.src.Name][path]
+
+	return ok && !slices.Contains(r.Documents, d.ID)
+}
+
+// forget removes the records and file states of items a complete run did
+// not see, given the records of its source
+func (idx *Indexer) forget(namespace string, p *pipeline, records map[string]record) error {
+	seen := func(path string) bool {
+		_, ok := p.revisions[path]
+		return ok || p.failed[path]
+	}
+
+	for path := range records {
+		if seen(path) {
+			continue
+		}
+
+		if err := idx.state.delete(documentsBucket, documentKey(namespace, p.src.Name, path)); err != nil {
+			return err
+		}
+	}
+
+	prefix := p.src.Name + ":"
+
+	keys, err := idx.state.keys(filesBucket, prefix)
+
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if seen(p.src.path(strings.TrimPrefix(key, prefix))) {
+			continue
+		}
+
+		if err := idx.state.delete(filesBucket, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lookup marks the recorded documents found in the index as present
+func lookup(ctx context.Context, target index.Provider, records map[string]map[string]record, present map[string]bool) error {
+	var ids []string
+
+	for _, source := range records {
+		for _, r := range source {
+			for _, id := range r.Documents {
+				if !present[id] {
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+
+	for batch := range slices.Chunk(ids, 100) {
+		documents, err := target.Get(ctx, batch...)
+
+		if err != nil {
+			return err
+		}
+
+		for _, d := range documents {
+			present[d.ID] = true
+		}
+	}
+
+	return nil
+}
+
+// listAll returns all documents of the index
+func listAll(ctx context.Context, target index.Provider) ([]index.Document, error) {
+	var result []index.Document
+
+	var cursor string
+
+	for {
+		page, err := target.List(ctx, &index.ListOptions{
+			Limit:  to.Ptr(100),
+			Cursor: cursor,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, page.Items...)
+
+		cursor = page.Cursor
+
+		if cursor == "" {
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/adrianliechti/wingman-index/pkg/config"
+	"github.com/adrianliechti/wingman-index/pkg/index"
+	"github.com/adrianliechti/wingman-index/pkg/indexer"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+var _ indexer.Source = &Source{}
+
+// Source indexes the objects of an S3-compatible bucket
+type Source struct {
+	client *minio.Client
+
+	bucket string
+	prefix string
+
+	files config.Files
+
+	include *indexer.Patterns
+	exclude *indexer.Patterns
+}
+
+type Option func(*options)
+
+type options struct {
+	prefix string
+	region string
+
+	accessKey string
+	secretKey string
+
+	files config.Files
+}
+
+// WithPrefix limits the source to the keys below prefix
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithRegion sets the region of the bucket
+func WithRegion(region string) Option {
+	return func(o *options) {
+		o.region = region
+	}
+}
+
+// WithCredentials sets static credentials. Without them, credentials are
+// read from the AWS_* and MINIO_* environment variables or the instance role.
+func WithCredentials(accessKey, secretKey string) Option {
+	return func(o *options) {
+		o.accessKey = accessKey
+		o.secretKey = secretKey
+	}
+}
+
+// WithFiles sets the rules selecting the objects to index
+func WithFiles(files config.Files) Option {
+	return func(o *options) {
+		o.files = files
+	}
+}
+
+// New returns a source for bucket at endpoint, a host or URL; the scheme
+// defaults to https
+func New(endpoint, bucket string, opts ...Option) (*Source, error) {
+	if bucket == "" {
+		return nil, errors.New("bucket is required")
+	}
+
+	if endpoint == "" {
+		endpoint = "s3.amazonaws.com"
+	}
+
+	o := &options{}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	secure := true
+
+	if strings.Contains(endpoint, "://") {
+		u, err := url.Parse(endpoint)
+
+		if err != nil {
+			return nil, err
+		}
+
+		endpoint = u.Host
+		secure = u.Scheme != "http"
+	}
+
+	creds := credentials.NewChainCredentials([]credentials.Provider{
+		&credentials.EnvAWS{},
+		&credentials.EnvMinio{},
+		&credentials.IAM{},
+	})
+
+	if o.accessKey != "" {
+		creds = credentials.NewStaticV4(o.accessKey, o.secretKey, "")
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  creds,
+		Secure: secure,
+		Region: o.region,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.TrimLeft(o.prefix, "/")
+
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	s := &Source{
+		client: client,
+
+		bucket: bucket,
+		prefix: prefix,
+
+		files: o.files,
+
+		include: indexer.CompilePatterns("", o.files.Include),
+		exclude: indexer.CompilePatterns("", o.files.Exclude),
+	}
+
+	return s, nil
+}
+
+func (s *Source) Items(ctx context.Context) iter.Seq2[indexer.Item, error] {
+	return func(yield func(indexer.Item, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		objects := s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+			Prefix:    s.prefix,
+			Recursive: true,
+		})
+
+		for obj := range objects {
+			// listing errors leave the listing incomplete and are reported
+			// without a path
+			if obj.Err != nil {
+				yield(indexer.Item{}, obj.Err)
+				return
+			}
+
+			rel := strings.TrimPrefix(obj.Key, s.prefix)
+
+			if strings.HasSuffix(rel, "/") || !s.accepted(rel, obj.Size) {
+				continue
+			}
+
+			item := indexer.Item{
+				Path: "/" + rel,
+
+				Revision: strings.Trim(obj.ETag, `"`),
+
+				Size: obj.Size,
+				Time: obj.LastModified,
+
+				Metadata: index.Metadata{
+					"bucket": s.bucket,
+					"key":    obj.Key,
+				},
+			}
+
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *Source) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, s.prefix+strings.TrimPrefix(path, "/"), minio.GetObjectOptions{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the object is requested lazily
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, fmt.Errorf("%s: %w", path, fs.ErrNotExist)
+		}
+
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// accepted reports whether the object rel (relative to the prefix) is indexed
+func (s *Source) accepted(rel string, size int64) bool {
+	if !indexer.Supported(rel, s.files.Extensions) {
+		return false
+	}
+
+	if s.files.MaxSize > 0 && size > s.files.MaxSize {
+		return false
+	}
+
+	if !s.files.Hidden {
+		for _, part := range strings.Split(rel, "/") {
+			if strings.HasPrefix(part, ".") {
+				return false
+			}
+		}
+	}
+
+	for d := rel; d != "." && d != ""; d = path.Dir(d) {
+		if matched, ok := s.exclude.Match(d, d != rel); ok && matched {
+			return false
+		}
+	}
+
+	if !s.include.Empty() && !s.include.MatchAny(rel) {
+		return false
+	}
+
+	return true
+}
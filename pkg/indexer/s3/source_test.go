@@ -0,0 +1,111 @@
+package s3_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/adrianliechti/wingman-index/pkg/indexer"
+	"github.com/adrianliechti/wingman-index/pkg/indexer/s3"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func TestSource(t *testing.T) {
+	ctx := context.Background()
+
+	server, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		Started: true,
+
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "minio/minio:RELEASE.2025-04-22T22-12-26Z",
+			Cmd:          []string{"server", "/data"},
+			ExposedPorts: []string{"9000/tcp"},
+
+			Env: map[string]string{
+				"MINIO_ROOT_USER":     "minio",
+				"MINIO_ROOT_PASSWORD": "minio123",
+			},
+
+			WaitingFor: wait.ForHTTP("/minio/health/live").WithPort("9000/tcp"),
+		},
+	})
+
+	require.NoError(t, err)
+
+	endpoint, err := server.Endpoint(ctx, "")
+	require.NoError(t, err)
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds: credentials.NewStaticV4("minio", "minio123", ""),
+	})
+
+	require.NoError(t, err)
+	require.NoError(t, client.MakeBucket(ctx, "manuals", minio.MakeBucketOptions{}))
+
+	objects := map[string]string{
+		"docs/guide.md":        "# Guide",
+		"docs/reference.txt":   "Reference",
+		"docs/image.png":       "binary",
+		"docs/.drafts/wip.md":  "# WIP",
+		"other/unrelated.md":   "# Unrelated",
+		"docs/manual/intro.md": "# Intro",
+	}
+
+	for key, content := range objects {
+		_, err := client.PutObject(ctx, "manuals", key, bytes.NewReader([]byte(content)), int64(len(content)), minio.PutObjectOptions{})
+		require.NoError(t, err)
+	}
+
+	s, err := s3.New("http://"+endpoint, "manuals",
+		s3.WithPrefix("docs"),
+		s3.WithCredentials("minio", "minio123"),
+	)
+
+	require.NoError(t, err)
+
+	items := collect(t, s)
+	require.Equal(t, []string{"/guide.md", "/manual/intro.md", "/reference.txt"}, slices.Sorted(maps.Keys(items)))
+
+	guide := items["/guide.md"]
+
+	sum := md5.Sum([]byte("# Guide"))
+
+	require.Equal(t, hex.EncodeToString(sum[:]), guide.Revision)
+	require.Equal(t, int64(7), guide.Size)
+	require.Equal(t, "docs/guide.md", guide.Metadata["key"])
+
+	r, err := s.Open(ctx, "/guide.md")
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(r)
+	r.Close()
+
+	require.NoError(t, err)
+	require.Equal(t, "# Guide", string(data))
+
+	require.NoError(t, client.RemoveObject(ctx, "manuals", "docs/reference.txt", minio.RemoveObjectOptions{}))
+
+	items = collect(t, s)
+	require.Equal(t, []string{"/guide.md", "/manual/intro.md"}, slices.Sorted(maps.Keys(items)))
+}
+
+func collect(t *testing.T, s *s3.Source) map[string]indexer.Item {
+	result := map[string]indexer.Item{}
+
+	for item, err := range s.Items(context.Background()) {
+		require.NoError(t, err)
+		result[item.Path] = item
+	}
+
+	return result
+}
@@ -0,0 +1,75 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/adrianliechti/wingman-index/pkg/to"
+	"github.com/adrianliechti/wingman/pkg/client"
+)
+
+// Segmenter splits the extracted text of a file into segments to embed.
+// Segmenters implementing fmt.Stringer describe their settings with it;
+// cached segments are redone when the description changes.
+type Segmenter interface {
+	Segment(ctx context.Context, name, text string) ([]Segment, error)
+}
+
+// segmentation returns the description of a segmenter's settings
+func segmentation(s Segmenter) string {
+	if s, ok := s.(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	return ""
+}
+
+// ClientSegmenter segments text using the wingman segmentation API
+type ClientSegmenter struct {
+	client *client.Client
+
+	size    int
+	overlap int
+}
+
+func NewClientSegmenter(client *client.Client, size, overlap int) *ClientSegmenter {
+	return &ClientSegmenter{
+		client: client,
+
+		size:    size,
+		overlap: overlap,
+	}
+}
+
+func (s *ClientSegmenter) Segment(ctx context.Context, name, text string) ([]Segment, error) {
+	segments, err := s.client.Segments.New(ctx, client.SegmentRequest{
+		// the text is extracted already
+		Name:   "content.txt",
+		Reader: strings.NewReader(text),
+
+		SegmentLength:  to.Ptr(s.size),
+		SegmentOverlap: to.Ptr(s.overlap),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Segment
+
+	for _, segment := range segments {
+		result = append(result, Segment{Text: segment.Text})
+	}
+
+	return result, nil
+}
+
+func (s *ClientSegmenter) String() string {
+	// segments cached before segmenters were configurable used these settings
+	if s.size == 3000 && s.overlap == 1500 {
+		return ""
+	}
+
+	return fmt.Sprintf("wingman-%d-%d", s.size, s.overlap)
+}
@@ -0,0 +1,386 @@
+package indexer
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// languages maps the extensions of source code files to their language
+var languages = map[string]string{
+	".go": "go",
+
+	".py":  "python",
+	".rb":  "ruby",
+	".php": "php",
+
+	".js":  "javascript",
+	".jsx": "javascript",
+	".mjs": "javascript",
+	".ts":  "typescript",
+	".tsx": "typescript",
+
+	".java":  "java",
+	".kt":    "kotlin",
+	".scala": "scala",
+	".cs":    "csharp",
+	".swift": "swift",
+
+	".c":   "c",
+	".h":   "c",
+	".cc":  "cpp",
+	".cpp": "cpp",
+	".hpp": "cpp",
+	".rs":  "rust",
+
+	".sh":  "shell",
+	".sql": "sql",
+}
+
+// Language returns the programming language of a source code file, or an
+// empty string for other files
+func Language(name string) string {
+	return languages[strings.ToLower(path.Ext(name))]
+}
+
+// CodeSegmenter splits source code at declarations and records the symbol,
+// kind and line range of each segment. Go files are parsed; other languages
+// are split at top-level declarations recognized by keywords. Other files
+// are passed to the next segmenter.
+type CodeSegmenter struct {
+	next Segmenter
+
+	// size is the maximum length of a segment in characters; longer
+	// declarations are split at lines
+	size int
+}
+
+func NewCodeSegmenter(next Segmenter, size int) *CodeSegmenter {
+	return &CodeSegmenter{
+		next: next,
+		size: max(size, 1),
+	}
+}
+
+// String describes the settings of the next segmenter, so caches of other
+// files stay valid
+func (s *CodeSegmenter) String() string {
+	return segmentation(s.next)
+}
+
+func (s *CodeSegmenter) Segment(ctx context.Context, name, text string) ([]Segment, error) {
+	language := Language(name)
+
+	if language == "" {
+		return s.next.Segment(ctx, name, text)
+	}
+
+	var chunks []chunk
+
+	if language == "go" {
+		chunks = goChunks(name, text)
+	}
+
+	if chunks == nil {
+		chunks = genericChunks(text)
+	}
+
+	var result []Segment
+
+	for _, c := range chunks {
+		for _, part := range c.split(s.size) {
+			segment := Segment{
+				Text: part.text,
+
+				Metadata: map[string]any{
+					"start_line": int64(part.start),
+					"end_line":   int64(part.end),
+				},
+			}
+
+			if c.symbol != "" {
+				segment.Metadata["symbol"] = c.symbol
+			}
+
+			if c.kind != "" {
+				segment.Metadata["kind"] = c.kind
+			}
+
+			if c.pkg != "" {
+				segment.Metadata["package"] = c.pkg
+			}
+
+			result = append(result, segment)
+		}
+	}
+
+	return result, nil
+}
+
+// chunk is a declaration or another range of lines of a source file
+type chunk struct {
+	text string
+
+	// start and end are the first and last line, counting from 1
+	start int
+	end   int
+
+	symbol string
+	kind   string
+	pkg    string
+}
+
+// split cuts a chunk longer than size at lines
+func (c chunk) split(size int) []chunk {
+	if len(c.text) <= size {
+		return []chunk{c}
+	}
+
+	var result []chunk
+
+	part := c
+	part.text = ""
+
+	line := c.start
+
+	for _, l := range strings.SplitAfter(c.text, "\n") {
+		if part.text != "" && len(part.text)+len(l) > size {
+			part.end = line - 1
+			result = append(result, part)
+
+			part.text = ""
+			part.start = line
+		}
+
+		part.text += l
+		line++
+	}
+
+	if strings.TrimSpace(part.text) != "" {
+		part.end = c.end
+		result = append(result, part)
+	}
+
+	return result
+}
+
+// goChunks splits Go source into the package clause with imports and one
+// chunk per declaration including its doc comment. It returns nil if the
+// source does not parse.
+func goChunks(name, text string) []chunk {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, name, text, parser.ParseComments)
+
+	if err != nil {
+		return nil
+	}
+
+	pkg := file.Name.Name
+
+	var result []chunk
+
+	add := func(start, end token.Pos, symbol, kind string) {
+		s := fset.Position(start)
+		e := fset.Position(end)
+
+		result = append(result, chunk{
+			text: text[s.Offset:e.Offset],
+
+			start: s.Line,
+			end:   e.Line,
+
+			symbol: symbol,
+			kind:   kind,
+			pkg:    pkg,
+		})
+	}
+
+	// the package clause includes the package documentation and the imports
+	header := file.Name.End()
+
+	for _, decl := range file.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok && d.Tok == token.IMPORT {
+			header = d.End()
+		}
+	}
+
+	start := file.Package
+
+	if file.Doc != nil {
+		start = file.Doc.Pos()
+	}
+
+	add(start, header, pkg, "package")
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			start := d.Pos()
+
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+
+			symbol := d.Name.Name
+			kind := "function"
+
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				symbol = receiverName(d.Recv.List[0].Type) + "." + symbol
+				kind = "method"
+			}
+
+			add(start, d.End(), symbol, kind)
+
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+
+			start := d.Pos()
+
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+
+			var names []string
+
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, s.Name.Name)
+
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						names = append(names, n.Name)
+					}
+				}
+			}
+
+			add(start, d.End(), strings.Join(names, ", "), d.Tok.String())
+		}
+	}
+
+	return result
+}
+
+// receiverName returns the type name of a method receiver
+func receiverName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(e.X)
+
+	case *ast.IndexExpr:
+		return receiverName(e.X)
+
+	case *ast.IndexListExpr:
+		return receiverName(e.X)
+
+	case *ast.Ident:
+		return e.Name
+	}
+
+	return ""
+}
+
+var (
+	declarationPattern      = regexp.MustCompile(`^(?:(?:export|default|public|private|protected|internal|static|abstract|final|sealed|async|pub(?:\([^)]*\))?|unsafe|extern|inline|virtual)\s+)*(def|class|function|func|fn|interface|struct|enum|trait|impl|module|object|record|type|CREATE\s+(?:OR\s+REPLACE\s+)?(?:TABLE|VIEW|FUNCTION|PROCEDURE))\b\*?\s*(?:<[^>]*>\s*)?([A-Za-z_$][\w$]*)?`)
+	assignedFunctionPattern = regexp.MustCompile(`^(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*=>|[A-Za-z_$][\w$]*\s*=>)`)
+	attachedPattern         = regexp.MustCompile(`^(?://|#|/\*|\*|@|--|\[)`)
+)
+
+// genericChunks splits source code at unindented declarations, keeping
+// comments and decorators directly above them
+func genericChunks(text string) []chunk {
+	lines := strings.SplitAfter(text, "\n")
+
+	type boundary struct {
+		line   int
+		symbol string
+		kind   string
+	}
+
+	boundaries := []boundary{{line: 0}}
+
+	for i, line := range lines {
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+
+		var symbol, kind string
+
+		if m := declarationPattern.FindStringSubmatch(line); m != nil {
+			symbol, kind = m[2], declarationKind(m[1])
+		} else if m := assignedFunctionPattern.FindStringSubmatch(line); m != nil {
+			symbol, kind = m[1], "function"
+		} else {
+			continue
+		}
+
+		start := i
+
+		for start > 0 && attachedPattern.MatchString(lines[start-1]) {
+			start--
+		}
+
+		if last := boundaries[len(boundaries)-1]; start <= last.line {
+			// declarations sharing their comment block start one chunk
+			if last.kind == "" {
+				boundaries[len(boundaries)-1] = boundary{line: last.line, symbol: symbol, kind: kind}
+			}
+
+			continue
+		}
+
+		boundaries = append(boundaries, boundary{line: start, symbol: symbol, kind: kind})
+	}
+
+	var result []chunk
+
+	for i, b := range boundaries {
+		end := len(lines)
+
+		if i+1 < len(boundaries) {
+			end = boundaries[i+1].line
+		}
+
+		text := strings.Join(lines[b.line:end], "")
+
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		result = append(result, chunk{
+			text: strings.TrimRight(text, "\n") + "\n",
+
+			start: b.line + 1,
+			end:   b.line + strings.Count(strings.TrimRight(text, "\n"), "\n") + 1,
+
+			symbol: b.symbol,
+			kind:   b.kind,
+		})
+	}
+
+	return result
+}
+
+// declarationKind normalizes declaration keywords
+func declarationKind(keyword string) string {
+	keyword = strings.ToLower(keyword)
+
+	switch keyword {
+	case "def", "func", "fn", "function":
+		return "function"
+	}
+
+	if strings.HasPrefix(keyword, "create") {
+		fields := strings.Fields(keyword)
+		return fields[len(fields)-1]
+	}
+
+	return keyword
+}
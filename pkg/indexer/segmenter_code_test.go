@@ -0,0 +1,113 @@
+package indexer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguage(t *testing.T) {
+	require.Equal(t, "go", Language("/cmd/main.go"))
+	require.Equal(t, "typescript", Language("/web/App.TSX"))
+	require.Equal(t, "", Language("/docs/guide.md"))
+}
+
+func TestCodeSegmenterGo(t *testing.T) {
+	ctx := context.Background()
+
+	text := `// Package server serves the index
+package server
+
+import "context"
+
+// Server handles requests
+type Server struct {
+	name string
+}
+
+// Query searches the index
+func (s *Server) Query(ctx context.Context) error {
+	return nil
+}
+
+const (
+	A = 1
+	B = 2
+)
+`
+
+	s := NewCodeSegmenter(NewTextSegmenter(), 2000)
+
+	segments, err := s.Segment(ctx, "/pkg/server/server.go", text)
+	require.NoError(t, err)
+	require.Len(t, segments, 4)
+
+	require.Equal(t, "// Package server serves the index\npackage server\n\nimport \"context\"", segments[0].Text)
+	require.Equal(t, "package", segments[0].Metadata["kind"])
+	require.Equal(t, int64(1), segments[0].Metadata["start_line"])
+	require.Equal(t, int64(4), segments[0].Metadata["end_line"])
+
+	require.Equal(t, "Server", segments[1].Metadata["symbol"])
+	require.Equal(t, "type", segments[1].Metadata["kind"])
+	require.Equal(t, int64(6), segments[1].Metadata["start_line"])
+
+	require.Equal(t, "// Query searches the index\nfunc (s *Server) Query(ctx context.Context) error {\n\treturn nil\n}", segments[2].Text)
+	require.Equal(t, "Server.Query", segments[2].Metadata["symbol"])
+	require.Equal(t, "method", segments[2].Metadata["kind"])
+	require.Equal(t, "server", segments[2].Metadata["package"])
+	require.Equal(t, int64(11), segments[2].Metadata["start_line"])
+	require.Equal(t, int64(14), segments[2].Metadata["end_line"])
+
+	require.Equal(t, "A, B", segments[3].Metadata["symbol"])
+	require.Equal(t, "const", segments[3].Metadata["kind"])
+}
+
+func TestCodeSegmenterGeneric(t *testing.T) {
+	ctx := context.Background()
+
+	text := `import os
+
+# loads the settings
+@cache
+def load(path):
+    return open(path).read()
+
+class Config:
+    def get(self, key):
+        return None
+`
+
+	s := NewCodeSegmenter(NewTextSegmenter(), 2000)
+
+	segments, err := s.Segment(ctx, "/app/config.py", text)
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+
+	require.Equal(t, "import os\n", segments[0].Text)
+	require.Nil(t, segments[0].Metadata["symbol"])
+
+	require.Equal(t, "# loads the settings\n@cache\ndef load(path):\n    return open(path).read()\n", segments[1].Text)
+	require.Equal(t, "load", segments[1].Metadata["symbol"])
+	require.Equal(t, "function", segments[1].Metadata["kind"])
+	require.Equal(t, int64(3), segments[1].Metadata["start_line"])
+	require.Equal(t, int64(6), segments[1].Metadata["end_line"])
+
+	require.Equal(t, "Config", segments[2].Metadata["symbol"])
+	require.Equal(t, "class", segments[2].Metadata["kind"])
+	require.Equal(t, int64(8), segments[2].Metadata["start_line"])
+	require.Equal(t, int64(10), segments[2].Metadata["end_line"])
+
+	// declarations longer than the segment size are split at lines
+	segments, err = NewCodeSegmenter(NewTextSegmenter(), 40).Segment(ctx, "/app/config.py", text)
+	require.NoError(t, err)
+
+	for _, segment := range segments {
+		require.LessOrEqual(t, len(segment.Text), 40)
+	}
+
+	// other files are passed on
+	segments, err = s.Segment(ctx, "/docs/guide.md", "# Guide\n\nHello")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Guide\n\nHello"}, texts(segments))
+}
@@ -0,0 +1,64 @@
+package indexer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextSegmenter(t *testing.T) {
+	ctx := context.Background()
+
+	s := NewTextSegmenter(WithSegmentSize(40), WithSegmentOverlap(20))
+
+	segments, err := s.Segment(ctx, "notes.txt", "One two three. Four five six. Seven eight nine. Ten eleven twelve.\n\nNext paragraph here.")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"One two three. Four five six.",
+		"Four five six. Seven eight nine.",
+		"Seven eight nine. Ten eleven twelve.",
+		"Ten eleven twelve.\n\nNext paragraph here.",
+	}, texts(segments))
+
+	segments, err = NewTextSegmenter().Segment(ctx, "guide.md", "# Guide\n\nIntro text.\n\n## Install\n\nStep one.\n\n```\n# not a heading\n```\n\n### Linux\n\nRun apt.\n\n## Usage ##\n\nUse it.")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"Guide\n\nIntro text.",
+		"Guide > Install\n\nStep one.\n\n```\n# not a heading\n```",
+		"Guide > Install > Linux\n\nRun apt.",
+		"Guide > Usage\n\nUse it.",
+	}, texts(segments))
+
+	// words longer than a segment are cut
+	segments, err = NewTextSegmenter(WithSegmentSize(10), WithSegmentOverlap(0)).Segment(ctx, "data.txt", strings.Repeat("x", 25))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"xxxxxxxxxx", "xxxxxxxxxx", "xxxxx"}, texts(segments))
+}
+
+func TestApproximateTokens(t *testing.T) {
+	require.Equal(t, 0, approximateTokens(""))
+	require.Equal(t, 6, approximateTokens("Hello, world!"))
+	require.Equal(t, 3, approximateTokens("segmentation"))
+
+	s := NewTextSegmenter(WithSegmentSize(8), WithSegmentOverlap(0), WithTokenLength())
+
+	segments, err := s.Segment(context.Background(), "notes.txt", "One two three. Four five six. Seven.")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"One two three.", "Four five six. Seven."}, texts(segments))
+}
+
+func texts(segments []Segment) []string {
+	var result []string
+
+	for _, s := range segments {
+		result = append(result, s.Text)
+	}
+
+	return result
+}
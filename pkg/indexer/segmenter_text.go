@@ -0,0 +1,399 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TextSegmenter splits text at paragraph and sentence boundaries. In markdown
+// and in text extracted locally from HTML and Office files, headings start
+// new segments and each segment begins with its heading breadcrumb.
+type TextSegmenter struct {
+	size    int
+	overlap int
+
+	tokens bool
+}
+
+type SegmenterOption func(*TextSegmenter)
+
+// WithSegmentSize sets the maximum length of a segment
+func WithSegmentSize(size int) SegmenterOption {
+	return func(s *TextSegmenter) {
+		s.size = size
+	}
+}
+
+// WithSegmentOverlap sets how much of the end of a segment is repeated at the
+// start of the next one
+func WithSegmentOverlap(overlap int) SegmenterOption {
+	return func(s *TextSegmenter) {
+		s.overlap = overlap
+	}
+}
+
+// WithTokenLength measures lengths in approximate tokens instead of characters
+func WithTokenLength() SegmenterOption {
+	return func(s *TextSegmenter) {
+		s.tokens = true
+	}
+}
+
+func NewTextSegmenter(options ...SegmenterOption) *TextSegmenter {
+	s := &TextSegmenter{
+		size:    2000,
+		overlap: 200,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	s.size = max(s.size, 1)
+	s.overlap = min(max(s.overlap, 0), s.size/2)
+
+	return s
+}
+
+func (s *TextSegmenter) String() string {
+	unit := "chars"
+
+	if s.tokens {
+		unit = "tokens"
+	}
+
+	return fmt.Sprintf("text-%d-%d-%s", s.size, s.overlap, unit)
+}
+
+func (s *TextSegmenter) Segment(ctx context.Context, name, text string) ([]Segment, error) {
+	var result []Segment
+
+	add := func(texts ...string) {
+		for _, t := range texts {
+			result = append(result, Segment{Text: t})
+		}
+	}
+
+	if !hasHeadings(name) {
+		add(s.split(text, s.size)...)
+		return result, nil
+	}
+
+	for _, section := range markdownSections(text) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		breadcrumb := strings.Join(section.headings, " > ")
+
+		if breadcrumb == "" {
+			add(s.split(section.body, s.size)...)
+			continue
+		}
+
+		prefix := breadcrumb + "\n\n"
+
+		// keep room for the content if the breadcrumb is long
+		budget := max(s.size-s.length(prefix), s.size/2)
+
+		for _, segment := range s.split(section.body, budget) {
+			add(prefix + segment)
+		}
+	}
+
+	return result, nil
+}
+
+// hasHeadings reports whether the text of the file uses markdown headings
+func hasHeadings(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md", ".markdown", ".mdx", ".html", ".htm", ".xhtml", ".docx", ".pptx", ".xlsx":
+		return true
+	}
+
+	return false
+}
+
+// length measures text in characters or approximate tokens
+func (s *TextSegmenter) length(text string) int {
+	if !s.tokens {
+		return utf8.RuneCountInString(text)
+	}
+
+	return approximateTokens(text)
+}
+
+// approximateTokens estimates the number of tokens of BPE tokenizers as
+// used by common embedding models: one per punctuation mark and one per
+// four characters of each word
+func approximateTokens(text string) int {
+	count := 0
+	word := 0
+
+	flush := func() {
+		count += (word + 3) / 4
+		word = 0
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word++
+
+		case unicode.IsSpace(r):
+			flush()
+
+		default:
+			flush()
+			count++
+		}
+	}
+
+	flush()
+
+	return count
+}
+
+// unit is a paragraph, sentence or piece of a sentence
+type unit struct {
+	text string
+
+	// paragraph is set if the unit starts a paragraph
+	paragraph bool
+}
+
+// split packs paragraphs, or sentences of paragraphs that are too long, into
+// segments of at most size
+func (s *TextSegmenter) split(text string, size int) []string {
+	var units []unit
+
+	for _, p := range paragraphPattern.Split(strings.TrimSpace(text), -1) {
+		p = strings.TrimSpace(p)
+
+		if p == "" {
+			continue
+		}
+
+		if s.length(p) <= size {
+			units = append(units, unit{text: p, paragraph: true})
+			continue
+		}
+
+		for i, sentence := range s.pieces(p, size) {
+			units = append(units, unit{text: sentence, paragraph: i == 0})
+		}
+	}
+
+	var result []string
+	var current []unit
+
+	length := func(units []unit) int {
+		return s.length(join(units))
+	}
+
+	for _, u := range units {
+		if len(current) > 0 && length(append(current, u)) > size {
+			result = append(result, join(current))
+
+			current = s.tail(current, size-s.length(u.text)-2)
+		}
+
+		current = append(current, u)
+	}
+
+	if len(current) > 0 {
+		result = append(result, join(current))
+	}
+
+	return result
+}
+
+// tail returns the trailing units of a segment to repeat in the next one,
+// at most the overlap and the room left next to the following unit
+func (s *TextSegmenter) tail(units []unit, room int) []unit {
+	limit := min(s.overlap, room)
+
+	i := len(units)
+
+	for i > 0 && s.length(join(units[i-1:])) <= limit {
+		i--
+	}
+
+	// the whole segment is never repeated
+	if i == 0 {
+		i = 1
+	}
+
+	return append([]unit(nil), units[i:]...)
+}
+
+// pieces splits a paragraph into sentences; sentences longer than size are
+// split at words, and words longer than size at characters
+func (s *TextSegmenter) pieces(paragraph string, size int) []string {
+	var result []string
+
+	for _, sentence := range sentences(paragraph) {
+		if s.length(sentence) <= size {
+			result = append(result, sentence)
+			continue
+		}
+
+		var current string
+
+		for _, word := range strings.Fields(sentence) {
+			candidate := word
+
+			if current != "" {
+				candidate = current + " " + word
+			}
+
+			if s.length(candidate) <= size {
+				current = candidate
+				continue
+			}
+
+			if current != "" {
+				result = append(result, current)
+			}
+
+			current = ""
+
+			for s.length(word) > size {
+				runes := []rune(word)
+
+				n := min(size, len(runes))
+
+				for n > 1 && s.length(string(runes[:n])) > size {
+					n--
+				}
+
+				result = append(result, string(runes[:n]))
+				word = string(runes[n:])
+			}
+
+			current = word
+		}
+
+		if current != "" {
+			result = append(result, current)
+		}
+	}
+
+	return result
+}
+
+var (
+	paragraphPattern = regexp.MustCompile(`\n[ \t]*\n`)
+	sentencePattern  = regexp.MustCompile(`[.!?。！？]+["'”’)\]]*\s+`)
+)
+
+// sentences splits text after sentence-ending punctuation followed by space
+func sentences(text string) []string {
+	var result []string
+
+	start := 0
+
+	for _, m := range sentencePattern.FindAllStringIndex(text, -1) {
+		if s := strings.TrimSpace(text[start:m[1]]); s != "" {
+			result = append(result, s)
+		}
+
+		start = m[1]
+	}
+
+	if s := strings.TrimSpace(text[start:]); s != "" {
+		result = append(result, s)
+	}
+
+	return result
+}
+
+func join(units []unit) string {
+	var sb strings.Builder
+
+	for i, u := range units {
+		if i > 0 {
+			if u.paragraph {
+				sb.WriteString("\n\n")
+			} else {
+				sb.WriteString(" ")
+			}
+		}
+
+		sb.WriteString(u.text)
+	}
+
+	return sb.String()
+}
+
+// section is the text below a heading
+type section struct {
+	headings []string
+	body     string
+}
+
+var headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+
+// markdownSections splits markdown at headings outside of code blocks
+func markdownSections(text string) []section {
+	type heading struct {
+		level int
+		title string
+	}
+
+	var result []section
+	var stack []heading
+
+	var body strings.Builder
+
+	flush := func() {
+		if strings.TrimSpace(body.String()) == "" {
+			body.Reset()
+			return
+		}
+
+		var titles []string
+
+		for _, h := range stack {
+			titles = append(titles, h.title)
+		}
+
+		result = append(result, section{headings: titles, body: body.String()})
+		body.Reset()
+	}
+
+	fence := ""
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			fence = trimmed[:3]
+		} else if fence != "" && strings.HasPrefix(trimmed, fence) {
+			fence = ""
+		} else if m := headingPattern.FindStringSubmatch(line); fence == "" && m != nil {
+			flush()
+
+			level := len(m[1])
+
+			for len(stack) > 0 && stack[len(stack)-1].level >= level {
+				stack = stack[:len(stack)-1]
+			}
+
+			stack = append(stack, heading{level: level, title: m[2]})
+			continue
+		}
+
+		body.WriteString(line)
+		body.WriteString("\n")
+	}
+
+	flush()
+
+	return result
+}
@@ -0,0 +1,132 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// directoryMetadataFile holds metadata of all items in its directory and below
+	directoryMetadataFile = "_meta.yaml"
+
+	// fileMetadataSuffix is appended to the name of an item to name its metadata file
+	fileMetadataSuffix = ".meta.json"
+)
+
+// IsSidecar reports whether p is a metadata file of other items rather than
+// an item itself
+func IsSidecar(p string) bool {
+	name := path.Base(p)
+	return name == directoryMetadataFile || strings.HasSuffix(name, fileMetadataSuffix)
+}
+
+// directoryFields returns the metadata of the directory sidecars from the
+// root down to the directory of item, deeper ones overriding their parents
+func (p *pipeline) directoryFields(ctx context.Context, item string) (index.Metadata, error) {
+	var dirs []string
+
+	for dir := path.Dir(item); ; dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+
+		if dir == "/" || dir == "." {
+			break
+		}
+	}
+
+	slices.Reverse(dirs)
+
+	result := index.Metadata{}
+
+	for _, dir := range dirs {
+		fields, err := p.directorySidecar(ctx, dir)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for k, v := range fields {
+			result[k] = v
+		}
+	}
+
+	return result, nil
+}
+
+// directorySidecar reads the sidecar of a directory once per run
+func (p *pipeline) directorySidecar(ctx context.Context, dir string) (index.Metadata, error) {
+	p.sidecarsMu.Lock()
+	defer p.sidecarsMu.Unlock()
+
+	if fields, ok := p.sidecars[dir]; ok {
+		return fields, nil
+	}
+
+	fields, err := p.readSidecar(ctx, path.Join(dir, directoryMetadataFile), yaml.Unmarshal)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.sidecars == nil {
+		p.sidecars = make(map[string]index.Metadata)
+	}
+
+	p.sidecars[dir] = fields
+
+	return fields, nil
+}
+
+// fileSidecar reads the sidecar of an item
+func (p *pipeline) fileSidecar(ctx context.Context, item string) (index.Metadata, error) {
+	return p.readSidecar(ctx, item+fileMetadataSuffix, unmarshalJSON)
+}
+
+// unmarshalJSON decodes numbers as json.Number, so integers and floats keep
+// their types
+func unmarshalJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	return dec.Decode(v)
+}
+
+// readSidecar reads a metadata file of the source; a missing file has no fields
+func (p *pipeline) readSidecar(ctx context.Context, name string, unmarshal func([]byte, any) error) (index.Metadata, error) {
+	r, err := p.src.Source.Open(ctx, name)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]any{}
+
+	if err := unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return normalizeFields(fields), nil
+}
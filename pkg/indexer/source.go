@@ -0,0 +1,156 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+)
+
+// Source enumerates the items to be indexed
+type Source interface {
+	// Items yields all items of the source. Errors for single items are
+	// yielded alongside the item path and do not stop the iteration.
+	Items(ctx context.Context) iter.Seq2[Item, error]
+
+	// Open returns the content of the item at path, or an error wrapping
+	// fs.ErrNotExist if there is none
+	Open(ctx context.Context, path string) (io.ReadCloser, error)
+}
+
+// Watcher is implemented by sources that report changes as they happen
+type Watcher interface {
+	// Watch calls fn with the paths of changed or deleted items until ctx is done
+	Watch(ctx context.Context, fn func(paths []string)) error
+
+	// Stat returns the item at path, or an error wrapping fs.ErrNotExist if it
+	// no longer exists or is no longer indexed
+	Stat(ctx context.Context, path string) (*Item, error)
+}
+
+// Item is a single file or document of a source
+type Item struct {
+	// Path identifies the item within its source, slash separated with a leading slash
+	Path string
+
+	// Revision changes whenever the content changes. If empty, the indexer
+	// hashes the content.
+	Revision string
+
+	Size int64
+	Time time.Time
+
+	// Title overrides the title derived from the name or content
+	Title string
+
+	// Link points to the item in an external system, e.g. a web UI. It is
+	// used as document source instead of the path if set.
+	Link string
+
+	// Metadata is added to the documents of the item
+	Metadata index.Metadata
+}
+
+// SourceConfig binds a source to a namespace of the index
+type SourceConfig struct {
+	// Name identifies the source; it is stored as "source" in document metadata
+	Name string
+
+	Source Source
+
+	// Namespace overrides the namespace the documents are written to
+	Namespace string
+
+	// Prefix is prepended to item paths to keep sources sharing a namespace apart
+	Prefix string
+
+	// Metadata is added to all documents of the source
+	Metadata index.Metadata
+}
+
+// source is a configured source
+type source struct {
+	SourceConfig
+}
+
+func (s *source) path(item string) string {
+	return strings.TrimRight(s.Prefix, "/") + item
+}
+
+// owns reports whether a document in the namespace was indexed from this source
+func (s *source) owns(d index.Document) bool {
+	if name := d.Metadata.String("source"); name != "" && name != s.Name {
+		return false
+	}
+
+	return strings.HasPrefix(d.Metadata.String("filepath"), strings.TrimRight(s.Prefix, "/")+"/")
+}
+
+// AddSource registers a source to be indexed
+func (idx *Indexer) AddSource(cfg SourceConfig) error {
+	if cfg.Source == nil {
+		return errors.New("source is required")
+	}
+
+	if cfg.Name == "" {
+		return errors.New("source name is required")
+	}
+
+	if cfg.Namespace == "" {
+		cfg.Namespace = idx.Namespace
+	}
+
+	for _, s := range idx.sources {
+		if s.Name == cfg.Name {
+			return errors.New("duplicate source: " + cfg.Name)
+		}
+	}
+
+	idx.sources = append(idx.sources, &source{cfg})
+
+	return nil
+}
+
+// namespaces returns the namespaces sources are bound to
+func (idx *Indexer) namespaces() []string {
+	var result []string
+
+	for _, s := range idx.sources {
+		if !slices.Contains(result, s.Namespace) {
+			result = append(result, s.Namespace)
+		}
+	}
+
+	return result
+}
+
+// target returns the provider writing to the given namespace
+func (idx *Indexer) target(namespace string) (index.Provider, error) {
+	if idx.Index == nil || namespace == idx.Namespace {
+		return idx.Index, nil
+	}
+
+	m, ok := idx.Index.(index.NamespaceManager)
+
+	if !ok {
+		return nil, errors.New("index does not support namespaces")
+	}
+
+	return m.WithNamespace(namespace), nil
+}
+
+// Supported reports whether the extension of p is in extensions, or in
+// DefaultExtensions if extensions is empty
+func Supported(p string, extensions []string) bool {
+	if len(extensions) == 0 {
+		extensions = DefaultExtensions
+	}
+
+	return slices.Contains(extensions, strings.ToLower(path.Ext(p)))
+}
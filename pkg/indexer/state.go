@@ -0,0 +1,406 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// filesBucket maps source:path to the fileState of an item
+	filesBucket = []byte("files")
+
+	// contentBucket maps content keys to the extracted text
+	contentBucket = []byte("content")
+
+	// embeddingsBucket maps content keys, models and segmentations to the
+	// embedded segments
+	embeddingsBucket = []byte("embeddings")
+
+	// documentsBucket maps namespaces, sources and document paths to the
+	// record of what was pushed to the index
+	documentsBucket = []byte("documents")
+
+	// usedBucket maps content keys to the time they were last used
+	usedBucket = []byte("used")
+)
+
+// state is the persistent state of the indexer, kept in a single database
+// file. Each value is written in a transaction, so an interrupted run resumes
+// with the work completed so far.
+type state struct {
+	db *bolt.DB
+}
+
+// record is what was pushed to the index for an item
+type record struct {
+	Source string `json:"source"`
+
+	Revision string `json:"revision"`
+
+	Model        string `json:"model"`
+	Segmentation string `json:"segmentation"`
+
+	// Metadata is the metadata of the item set by its source
+	Metadata map[string]any `json:"metadata,omitempty"`
+
+	// Fields are read from front matter and sidecar metadata files
+	Fields map[string]any `json:"fields,omitempty"`
+
+	// Chunks are the hashes of the segment texts
+	Chunks []string `json:"chunks"`
+
+	// Documents are the IDs of the documents in the index
+	Documents []string `json:"documents"`
+
+	Indexed time.Time `json:"indexed"`
+}
+
+func openState(dir string) (*state, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(filepath.Join(dir, "index.db"), 0644, &bolt.Options{Timeout: 10 * time.Second})
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{filesBucket, contentBucket, embeddingsBucket, documentsBucket, usedBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		return migrateRecords(tx.Bucket(documentsBucket))
+	})
+
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &state{db: db}, nil
+}
+
+func (s *state) Close() error {
+	return s.db.Close()
+}
+
+// get decodes the value of key into v and reports whether it exists
+func (s *state) get(bucket []byte, key string, v any) (bool, error) {
+	var data []byte
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data = bytes.Clone(tx.Bucket(bucket).Get([]byte(key)))
+		return nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	if data == nil {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *state) has(bucket []byte, key string) (bool, error) {
+	var ok bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		ok = tx.Bucket(bucket).Get([]byte(key)) != nil
+		return nil
+	})
+
+	return ok, err
+}
+
+// keys returns the keys of a bucket with the given prefix
+func (s *state) keys(bucket []byte, prefix string) ([]string, error) {
+	var result []string
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+
+		for k, _ := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, _ = c.Next() {
+			result = append(result, string(k))
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+func (s *state) put(bucket []byte, key string, v any) error {
+	data, err := json.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(key), data)
+	})
+}
+
+func (s *state) delete(bucket []byte, key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete([]byte(key))
+	})
+}
+
+// deleteTree removes key and the keys below it, separated by a slash
+func (s *state) deleteTree(bucket []byte, key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+
+		if err := b.Delete([]byte(key)); err != nil {
+			return err
+		}
+
+		_, err := deletePrefix(b, []byte(key+"/"))
+		return err
+	})
+}
+
+// embeddingsKey identifies the segments of content embedded by a model
+func embeddingsKey(key, model, segmentation string) string {
+	return key + "\x00" + model + "\x00" + segmentation
+}
+
+// documentKey identifies the record of a document path of a source in a
+// namespace. Sources sharing a namespace without prefixes may index the same
+// paths.
+func documentKey(namespace, source, path string) string {
+	return namespacePrefix(namespace) + source + "\x00" + path
+}
+
+// namespacePrefix is the common prefix of the document keys of a namespace
+func namespacePrefix(namespace string) string {
+	return namespace + "\x00"
+}
+
+// records returns the records of a namespace by source and document path
+func (s *state) records(namespace string) (map[string]map[string]record, error) {
+	result := map[string]map[string]record{}
+
+	prefix := []byte(namespacePrefix(namespace))
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(documentsBucket).Cursor()
+
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			source, path, ok := strings.Cut(string(k[len(prefix):]), "\x00")
+
+			if !ok {
+				continue
+			}
+
+			var r record
+
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+
+			if result[source] == nil {
+				result[source] = map[string]record{}
+			}
+
+			result[source][path] = r
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// migrateRecords adds the source to the keys of records written before
+// they were kept per source
+func migrateRecords(b *bolt.Bucket) error {
+	legacy := map[string][]byte{}
+
+	err := b.ForEach(func(k, v []byte) error {
+		if bytes.Count(k, []byte{0}) == 1 {
+			legacy[string(k)] = bytes.Clone(v)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for k, v := range legacy {
+		var r record
+
+		if err := json.Unmarshal(v, &r); err != nil {
+			return err
+		}
+
+		namespace, path, _ := strings.Cut(k, "\x00")
+
+		if err := b.Put([]byte(documentKey(namespace, r.Source, path)), v); err != nil {
+			return err
+		}
+
+		if err := b.Delete([]byte(k)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropRecords removes the records of a namespace
+func (s *state) dropRecords(namespace string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		_, err := deletePrefix(tx.Bucket(documentsBucket), []byte(namespacePrefix(namespace)))
+		return err
+	})
+}
+
+// moveRecords replaces the records of namespace to with those of from
+func (s *state) moveRecords(from, to string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(documentsBucket)
+
+		if _, err := deletePrefix(b, []byte(namespacePrefix(to))); err != nil {
+			return err
+		}
+
+		moved, err := deletePrefix(b, []byte(namespacePrefix(from)))
+
+		if err != nil {
+			return err
+		}
+
+		for suffix, v := range moved {
+			if err := b.Put([]byte(namespacePrefix(to)+suffix), v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// deletePrefix removes the keys with the given prefix and returns their
+// values by the rest of the key
+func deletePrefix(b *bolt.Bucket, prefix []byte) (map[string][]byte, error) {
+	result := map[string][]byte{}
+
+	c := b.Cursor()
+
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		result[string(k[len(prefix):])] = bytes.Clone(v)
+	}
+
+	for suffix := range result {
+		if err := b.Delete(append(bytes.Clone(prefix), suffix...)); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// collect records the use of the referenced content and, unless the run
+// was incomplete, removes content and embeddings unused since deadline
+func (s *state) collect(refs *references, deadline time.Time) (int, error) {
+	now, err := time.Now().MarshalText()
+
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		used := tx.Bucket(usedBucket)
+
+		for key := range refs.keys {
+			if err := used.Put([]byte(key), now); err != nil {
+				return err
+			}
+		}
+
+		if refs.incomplete {
+			return nil
+		}
+
+		var stale [][]byte
+
+		err := tx.Bucket(contentBucket).ForEach(func(k, v []byte) error {
+			if refs.keys[string(k)] {
+				return nil
+			}
+
+			var t time.Time
+
+			// content never marked as used starts its grace period now
+			if err := t.UnmarshalText(used.Get(k)); err != nil {
+				return used.Put(bytes.Clone(k), now)
+			}
+
+			if t.Before(deadline) {
+				stale = append(stale, bytes.Clone(k))
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		embeddings := tx.Bucket(embeddingsBucket)
+
+		for _, k := range stale {
+			var keys [][]byte
+
+			prefix := append(bytes.Clone(k), 0)
+			c := embeddings.Cursor()
+
+			for ek, _ := c.Seek(prefix); ek != nil && bytes.HasPrefix(ek, prefix); ek, _ = c.Next() {
+				keys = append(keys, bytes.Clone(ek))
+			}
+
+			for _, ek := range keys {
+				if err := embeddings.Delete(ek); err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Bucket(contentBucket).Delete(k); err != nil {
+				return err
+			}
+
+			if err := used.Delete(k); err != nil {
+				return err
+			}
+
+			removed++
+		}
+
+		return nil
+	})
+
+	return removed, err
+}
@@ -0,0 +1,249 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+)
+
+// Run indexes all sources and keeps the index up to date until ctx is done.
+// Sources implementing Watcher are indexed as they change if watching is
+// enabled; all sources are fully re-indexed every interval as a safety net.
+func (idx *Indexer) Run(ctx context.Context) error {
+	if err := idx.Sync(ctx); err != nil {
+		log.Printf("Error indexing sources: %v\n", err)
+	}
+
+	if idx.Watch {
+		for _, src := range idx.sources {
+			w, ok := src.Source.(Watcher)
+
+			if !ok {
+				continue
+			}
+
+			c := newChanges()
+
+			// changes are indexed apart from the watcher, so it keeps draining
+			// events while a sync holds the indexer
+			go func() {
+				for {
+					select {
+					case <-ctx.Done():
+						return
+
+					case <-c.ready:
+						if err := idx.indexPaths(ctx, src, c.take()); err != nil {
+							log.Printf("Error indexing changes of %s: %v\n", src.Name, err)
+						}
+					}
+				}
+			}()
+
+			go func() {
+				err := w.Watch(ctx, c.add)
+
+				if err != nil && !errors.Is(err, context.Canceled) {
+					log.Printf("Error watching %s, falling back to polling: %v\n", src.Name, err)
+				}
+			}()
+		}
+	}
+
+	ticker := time.NewTicker(idx.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-ticker.C:
+			if err := idx.Sync(ctx); err != nil {
+				log.Printf("Error indexing sources: %v\n", err)
+			}
+		}
+	}
+}
+
+// indexPaths indexes the given items of a watched source and removes the
+// documents of items that no longer exist
+func (idx *Indexer) indexPaths(ctx context.Context, src *source, paths []string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	w, ok := src.Source.(Watcher)
+
+	if !ok {
+		return errors.New("source does not support watching")
+	}
+
+	model, err := idx.probeEmbedder(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	target, err := idx.target(src.Namespace)
+
+	if err != nil {
+		return err
+	}
+
+	p := &pipeline{
+		Indexer: idx,
+
+		src:    src,
+		target: target,
+
+		namespace: src.Namespace,
+
+		model: model,
+	}
+
+	var result error
+
+	var jobs []*job
+
+	for _, path := range paths {
+		// changed directory sidecars are picked up by the next full sync
+		if IsSidecar(path) {
+			if !strings.HasSuffix(path, fileMetadataSuffix) {
+				continue
+			}
+
+			path = strings.TrimSuffix(path, fileMetadataSuffix)
+		}
+
+		item, err := w.Stat(ctx, path)
+
+		if errors.Is(err, fs.ErrNotExist) {
+			if err := idx.remove(ctx, src, target, path); err != nil {
+				result = errors.Join(result, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			result = errors.Join(result, err)
+			continue
+		}
+
+		jobs = append(jobs, &job{item: *item})
+	}
+
+	for j := range p.run(ctx, feed(ctx, jobs)) {
+		if j.count > 0 {
+			log.Printf("indexed %s (%d documents)", j.metadata.Path, j.count)
+		}
+	}
+
+	return errors.Join(result, p.err, ctx.Err())
+}
+
+// remove deletes the documents and state of an item that no longer exists.
+// If the item was a directory, e.g. moved out of the source, the items
+// recorded below it are removed as well.
+func (idx *Indexer) remove(ctx context.Context, src *source, target index.Provider, path string) error {
+	if err := idx.state.deleteTree(filesBucket, src.Name+":"+path); err != nil {
+		return err
+	}
+
+	if target == nil {
+		return nil
+	}
+
+	records, err := idx.state.records(src.Namespace)
+
+	if err != nil {
+		return err
+	}
+
+	name := src.path(path)
+
+	var paths []string
+	var ids []string
+
+	for p, r := range records[src.Name] {
+		if p != name && !strings.HasPrefix(p, name+"/") {
+			continue
+		}
+
+		paths = append(paths, p)
+		ids = append(ids, r.Documents...)
+	}
+
+	if len(ids) > 0 {
+		if err := target.Delete(ctx, ids...); err != nil {
+			return err
+		}
+	}
+
+	// documents indexed before their IDs were recorded; those indexed before
+	// their source was recorded are left to the reconcile of the next sync
+	if err := target.DeleteWhere(ctx, map[string]any{"source": src.Name, "filepath": name}); err != nil {
+		return err
+	}
+
+	for _, p := range paths {
+		if err := idx.state.delete(documentsBucket, documentKey(src.Namespace, src.Name, p)); err != nil {
+			return err
+		}
+
+		log.Printf("removed %s", p)
+	}
+
+	return nil
+}
+
+// changes collects the paths reported by a watcher until they are indexed
+type changes struct {
+	mu    sync.Mutex
+	paths map[string]bool
+
+	// ready is signalled when paths were added
+	ready chan struct{}
+}
+
+func newChanges() *changes {
+	return &changes{
+		paths: map[string]bool{},
+		ready: make(chan struct{}, 1),
+	}
+}
+
+// add records paths without blocking the watcher
+func (c *changes) add(paths []string) {
+	c.mu.Lock()
+
+	for _, p := range paths {
+		c.paths[p] = true
+	}
+
+	c.mu.Unlock()
+
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+// take returns and clears the recorded paths
+func (c *changes) take() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	paths := slices.Sorted(maps.Keys(c.paths))
+	clear(c.paths)
+
+	return paths
+}
@@ -0,0 +1,159 @@
+package indexer
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"iter"
+	"strings"
+	"testing"
+
+	"github.com/adrianliechti/wingman-index/pkg/config"
+	"github.com/adrianliechti/wingman-index/pkg/index"
+	"github.com/adrianliechti/wingman-index/pkg/index/memory"
+	"github.com/adrianliechti/wingman-index/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testSource is an in-memory source
+type testSource struct {
+	items map[string]string
+
+	// metadata is set on the items by path
+	metadata map[string]index.Metadata
+}
+
+func (s *testSource) Items(ctx context.Context) iter.Seq2[Item, error] {
+	return func(yield func(Item, error) bool) {
+		for path, content := range s.items {
+			if !yield(Item{Path: path, Size: int64(len(content)), Metadata: s.metadata[path]}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *testSource) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	content, ok := s.items[path]
+
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func (s *testSource) Watch(ctx context.Context, fn func(paths []string)) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (s *testSource) Stat(ctx context.Context, path string) (*Item, error) {
+	content, ok := s.items[path]
+
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+
+	return &Item{Path: path, Size: int64(len(content)), Metadata: s.metadata[path]}, nil
+}
+
+func TestIndexPathsRemovesDeletedFiles(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	err = c.Index(context.Context,
+		index.Document{ID: "1", Content: "a", Metadata: index.Metadata{"source": "docs", "filepath": "/docs/a.md"}},
+		index.Document{ID: "2", Content: "b", Metadata: index.Metadata{"source": "docs", "filepath": "/docs/b.md"}},
+		index.Document{ID: "3", Content: "a", Metadata: index.Metadata{"source": "other", "filepath": "/docs/a.md"}},
+	)
+	require.NoError(t, err)
+
+	idx, err := New(&config.Config{
+		Namespace: "default",
+
+		Index:    c,
+		Embedder: context.Embedder,
+
+		CacheDir: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	defer idx.Close()
+
+	err = idx.AddSource(SourceConfig{
+		Name:   "docs",
+		Source: &testSource{},
+	})
+	require.NoError(t, err)
+
+	err = idx.indexPaths(context.Context, idx.sources[0], []string{"/docs/a.md"})
+	require.NoError(t, err)
+
+	// documents of other sources at the same path are kept
+	docs, err := c.Get(context.Context, "1", "2", "3")
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	require.ElementsMatch(t, []string{"2", "3"}, []string{docs[0].ID, docs[1].ID})
+}
+
+func TestIndexPathsRemovesMovedDirectories(t *testing.T) {
+	context := test.NewContext()
+
+	c, err := memory.New(memory.WithEmbedder(context.Embedder))
+	require.NoError(t, err)
+
+	idx, err := New(&config.Config{
+		Namespace: "default",
+
+		Index:    c,
+		Embedder: context.Embedder,
+
+		CacheDir: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	defer idx.Close()
+
+	src := &testSource{
+		items: map[string]string{
+			"/docs/a.md":     "# A",
+			"/docs/sub/b.md": "# B",
+			"/docsfoo.md":    "# Foo",
+		},
+	}
+
+	require.NoError(t, idx.AddSource(SourceConfig{Name: "docs", Source: src}))
+	require.NoError(t, idx.Sync(context.Context))
+
+	delete(src.items, "/docs/a.md")
+	delete(src.items, "/docs/sub/b.md")
+
+	require.NoError(t, idx.indexPaths(context.Context, idx.sources[0], []string{"/docs"}))
+
+	require.Equal(t, []string{"/docsfoo.md#1"}, sources(listDocuments(t, c)))
+
+	records, err := idx.state.records("default")
+	require.NoError(t, err)
+	require.Len(t, records["docs"], 1)
+	require.Contains(t, records["docs"], "/docsfoo.md")
+
+	require.False(t, has(t, idx, filesBucket, "docs:/docs/a.md"))
+	require.True(t, has(t, idx, filesBucket, "docs:/docsfoo.md"))
+}
+
+func TestChanges(t *testing.T) {
+	c := newChanges()
+
+	// adding never blocks, even if nobody takes the changes yet
+	c.add([]string{"/b.md", "/a.md"})
+	c.add([]string{"/a.md"})
+
+	<-c.ready
+
+	require.Equal(t, []string{"/a.md", "/b.md"}, c.take())
+	require.Empty(t, c.take())
+}
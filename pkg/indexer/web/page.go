@@ -0,0 +1,95 @@
+package web
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// page holds what the crawler needs from an HTML page
+type page struct {
+	title     string
+	canonical string
+
+	links []string
+
+	// noindex and nofollow are set by the robots meta tag
+	noindex  bool
+	nofollow bool
+}
+
+// parsePage reads the title, canonical URL and links of an HTML page; URLs
+// are resolved against base
+func parsePage(r io.Reader, base *url.URL) (*page, error) {
+	doc, err := html.Parse(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := &page{}
+
+	var visit func(n *html.Node)
+
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "title":
+				if p.title == "" && n.FirstChild != nil {
+					p.title = strings.TrimSpace(n.FirstChild.Data)
+				}
+
+			case "base":
+				if u, err := base.Parse(attr(n, "href")); err == nil && attr(n, "href") != "" {
+					base = u
+				}
+
+			case "link":
+				if strings.EqualFold(attr(n, "rel"), "canonical") {
+					if u, err := base.Parse(attr(n, "href")); err == nil {
+						p.canonical = u.String()
+					}
+				}
+
+			case "meta":
+				if strings.EqualFold(attr(n, "name"), "robots") {
+					content := strings.ToLower(attr(n, "content"))
+
+					p.noindex = p.noindex || strings.Contains(content, "noindex") || strings.Contains(content, "none")
+					p.nofollow = p.nofollow || strings.Contains(content, "nofollow") || strings.Contains(content, "none")
+				}
+
+			case "a":
+				href := attr(n, "href")
+
+				if href == "" || strings.Contains(attr(n, "rel"), "nofollow") {
+					break
+				}
+
+				if u, err := base.Parse(href); err == nil {
+					p.links = append(p.links, u.String())
+				}
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+
+	visit(doc)
+
+	return p, nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,129 @@
+package web
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// robots holds the rules of a robots.txt file that apply to the crawler
+type robots struct {
+	rules []rule
+}
+
+type rule struct {
+	allow bool
+
+	length int
+	re     *regexp.Regexp
+}
+
+// parseRobots reads the rules of the group matching agent, or of the "*"
+// group if there is none
+func parseRobots(r io.Reader, agent string) *robots {
+	agent = strings.ToLower(agent)
+
+	groups := map[string][]rule{}
+
+	var current []string
+	var inRules bool
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+
+		key, value, ok := strings.Cut(line, ":")
+
+		if !ok {
+			continue
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// consecutive user-agent lines share a group
+			if inRules {
+				current = nil
+				inRules = false
+			}
+
+			current = append(current, strings.ToLower(value))
+
+		case "allow", "disallow":
+			inRules = true
+
+			// an empty disallow allows everything
+			if value == "" {
+				continue
+			}
+
+			r := rule{
+				allow:  key == "allow",
+				length: len(value),
+				re:     compileRule(value),
+			}
+
+			for _, a := range current {
+				groups[a] = append(groups[a], r)
+			}
+		}
+	}
+
+	for a, rules := range groups {
+		if a != "*" && strings.Contains(agent, a) {
+			return &robots{rules: rules}
+		}
+	}
+
+	return &robots{rules: groups["*"]}
+}
+
+// allowed reports whether the path (including the query) may be crawled. The
+// longest matching rule wins; allow rules win ties.
+func (r *robots) allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+
+	result := true
+	length := -1
+
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+
+		if rule.length > length || (rule.length == length && rule.allow) {
+			result = rule.allow
+			length = rule.length
+		}
+	}
+
+	return result
+}
+
+// compileRule converts a robots.txt path pattern with * and $ wildcards
+func compileRule(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+
+	sb.WriteString("^")
+
+	for i, c := range pattern {
+		switch {
+		case c == '*':
+			sb.WriteString(".*")
+
+		case c == '$' && i == len(pattern)-1:
+			sb.WriteString("$")
+
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return regexp.MustCompile(sb.String())
+}
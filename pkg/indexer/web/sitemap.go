@@ -0,0 +1,52 @@
+package web
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// sitemap is a sitemap.xml urlset or a sitemap index
+type sitemap struct {
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+func parseSitemap(r io.Reader) (*sitemap, error) {
+	var s sitemap
+
+	if err := xml.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (s *sitemap) urls() []string {
+	var result []string
+
+	for _, u := range s.URLs {
+		if loc := strings.TrimSpace(u.Loc); loc != "" {
+			result = append(result, loc)
+		}
+	}
+
+	return result
+}
+
+func (s *sitemap) sitemaps() []string {
+	var result []string
+
+	for _, m := range s.Sitemaps {
+		if loc := strings.TrimSpace(m.Loc); loc != "" {
+			result = append(result, loc)
+		}
+	}
+
+	return result
+}
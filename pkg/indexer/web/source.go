@@ -0,0 +1,601 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/adrianliechti/wingman-index/pkg/config"
+	"github.com/adrianliechti/wingman-index/pkg/index"
+	"github.com/adrianliechti/wingman-index/pkg/indexer"
+
+	"golang.org/x/time/rate"
+)
+
+var _ indexer.Source = &Source{}
+
+// errSkipped is returned for responses that are not indexed
+var errSkipped = errors.New("skipped")
+
+// Source crawls websites starting from seed URLs or sitemaps. Only pages on
+// the hosts of the seeds and sitemaps are crawled; query strings and
+// fragments are ignored.
+type Source struct {
+	client *http.Client
+	agent  string
+
+	seeds    []string
+	sitemaps []string
+
+	depth   int
+	limiter *rate.Limiter
+
+	files config.Files
+
+	// crawling serializes crawls
+	crawling sync.Mutex
+
+	// pages holds the responses of the last crawl by URL, used for
+	// conditional requests
+	pages map[string]*response
+
+	mu sync.Mutex
+
+	// urls maps item paths to their URL
+	urls map[string]string
+
+	// bodies holds the content of new or changed items fetched by the last
+	// crawl by item path until it is opened, saving a second request
+	bodies map[string][]byte
+}
+
+// response is a crawled page or document
+type response struct {
+	item indexer.Item
+
+	etag     string
+	modified string
+
+	page *page
+}
+
+type Option func(*Source)
+
+// WithSitemap adds the URLs of a sitemap.xml or sitemap index as seeds
+func WithSitemap(url string) Option {
+	return func(s *Source) {
+		s.sitemaps = append(s.sitemaps, url)
+	}
+}
+
+// WithDepth sets how many links are followed from the seeds; 0 crawls the
+// seeds only. Defaults to 3.
+func WithDepth(depth int) Option {
+	return func(s *Source) {
+		s.depth = depth
+	}
+}
+
+// WithRate limits the requests per second. Defaults to 5; 0 disables the limit.
+func WithRate(limit float64) Option {
+	return func(s *Source) {
+		if limit <= 0 {
+			s.limiter = nil
+			return
+		}
+
+		s.limiter = rate.NewLimiter(rate.Limit(limit), 1)
+	}
+}
+
+// WithUserAgent sets the user agent sent with requests and matched against
+// robots.txt
+func WithUserAgent(agent string) Option {
+	return func(s *Source) {
+		s.agent = agent
+	}
+}
+
+func WithClient(client *http.Client) Option {
+	return func(s *Source) {
+		s.client = client
+	}
+}
+
+// WithFiles sets the extensions of linked documents, e.g. PDFs, to index
+// besides HTML pages
+func WithFiles(files config.Files) Option {
+	return func(s *Source) {
+		s.files = files
+	}
+}
+
+func New(seeds []string, options ...Option) (*Source, error) {
+	s := &Source{
+		client: http.DefaultClient,
+		agent:  "wingman-index",
+
+		seeds: seeds,
+
+		depth:   3,
+		limiter: rate.NewLimiter(5, 1),
+
+		pages:  make(map[string]*response),
+		urls:   make(map[string]string),
+		bodies: make(map[string][]byte),
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	if len(s.seeds) == 0 && len(s.sitemaps) == 0 {
+		return nil, errors.New("seed urls or sitemap required")
+	}
+
+	for _, u := range append(s.seeds, s.sitemaps...) {
+		if _, err := parseURL(u); err != nil {
+			return nil, fmt.Errorf("invalid url %s: %w", u, err)
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Source) Items(ctx context.Context) iter.Seq2[indexer.Item, error] {
+	return func(yield func(indexer.Item, error) bool) {
+		s.crawling.Lock()
+		defer s.crawling.Unlock()
+
+		c := &crawl{
+			Source: s,
+
+			hosts:  map[string]bool{},
+			robots: map[string]*robots{},
+
+			seen:  map[string]bool{},
+			pages: map[string]*response{},
+			urls:  map[string]string{},
+		}
+
+		for _, u := range append(s.seeds, s.sitemaps...) {
+			if u, err := parseURL(u); err == nil {
+				c.hosts[u.Host] = true
+			}
+		}
+
+		s.mu.Lock()
+		clear(s.bodies)
+		s.mu.Unlock()
+
+		for _, u := range s.seeds {
+			c.enqueue(ctx, u, 0)
+		}
+
+		for _, u := range s.sitemaps {
+			urls, err := c.sitemap(ctx, u, 0)
+
+			// without the sitemap, the listing is incomplete
+			if err != nil {
+				yield(indexer.Item{}, err)
+				return
+			}
+
+			for _, u := range urls {
+				c.enqueue(ctx, u, 0)
+			}
+		}
+
+		yielded := map[string]bool{}
+
+		for len(c.queue) > 0 {
+			if ctx.Err() != nil {
+				yield(indexer.Item{}, ctx.Err())
+				return
+			}
+
+			t := c.queue[0]
+			c.queue = c.queue[1:]
+
+			r, err := c.fetch(ctx, t.url)
+
+			if errors.Is(err, errSkipped) {
+				continue
+			}
+
+			if err != nil {
+				// keep the documents of pages that fail temporarily
+				p := itemPath(t.url, path.Ext(t.url) == "")
+
+				if prev, ok := s.pages[t.url]; ok {
+					p = prev.item.Path
+				}
+
+				if !yield(indexer.Item{Path: p}, err) {
+					return
+				}
+
+				continue
+			}
+
+			if r.page != nil && !r.page.nofollow && t.depth < s.depth {
+				for _, link := range r.page.links {
+					c.enqueue(ctx, link, t.depth+1)
+				}
+			}
+
+			if r.page != nil && r.page.noindex {
+				continue
+			}
+
+			// pages reachable through several URLs are indexed once
+			if yielded[r.item.Link] {
+				continue
+			}
+
+			yielded[r.item.Link] = true
+
+			if !yield(r.item, nil) {
+				return
+			}
+		}
+
+		s.pages = c.pages
+
+		s.mu.Lock()
+		s.urls = c.urls
+		s.mu.Unlock()
+	}
+}
+
+func (s *Source) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	u, ok := s.urls[path]
+
+	data, fetched := s.bodies[path]
+	delete(s.bodies, path)
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown page: %s: %w", path, fs.ErrNotExist)
+	}
+
+	if fetched {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	resp, err := s.get(ctx, u, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", u, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+func (s *Source) get(ctx context.Context, u string, header http.Header) (*http.Response, error) {
+	if s.limiter != nil {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	req.Header.Set("User-Agent", s.agent)
+
+	return s.client.Do(req)
+}
+
+// crawl is the state of a single run over all seeds
+type crawl struct {
+	*Source
+
+	hosts  map[string]bool
+	robots map[string]*robots
+
+	queue []target
+	seen  map[string]bool
+
+	pages map[string]*response
+	urls  map[string]string
+}
+
+type target struct {
+	url   string
+	depth int
+}
+
+// enqueue adds u to the queue if it is in scope, allowed by robots.txt and
+// was not seen before
+func (c *crawl) enqueue(ctx context.Context, raw string, depth int) {
+	u, err := parseURL(raw)
+
+	if err != nil || !c.hosts[u.Host] {
+		return
+	}
+
+	key := u.String()
+
+	if c.seen[key] {
+		return
+	}
+
+	c.seen[key] = true
+
+	if !c.allowed(ctx, u) {
+		return
+	}
+
+	c.queue = append(c.queue, target{url: key, depth: depth})
+}
+
+// allowed checks the robots.txt of the host of u, fetched once per crawl
+func (c *crawl) allowed(ctx context.Context, u *url.URL) bool {
+	r, ok := c.robots[u.Host]
+
+	if !ok {
+		resp, err := c.get(ctx, u.Scheme+"://"+u.Host+"/robots.txt", nil)
+
+		if err == nil {
+			if resp.StatusCode == http.StatusOK {
+				r = parseRobots(resp.Body, c.agent)
+			}
+
+			resp.Body.Close()
+		}
+
+		c.robots[u.Host] = r
+	}
+
+	return r.allowed(u.EscapedPath())
+}
+
+// fetch requests u, reusing the previous response if it did not change
+func (c *crawl) fetch(ctx context.Context, u string) (*response, error) {
+	prev := c.Source.pages[u]
+
+	header := http.Header{}
+
+	if prev != nil {
+		if prev.etag != "" {
+			header.Set("If-None-Match", prev.etag)
+		}
+
+		if prev.modified != "" {
+			header.Set("If-Modified-Since", prev.modified)
+		}
+	}
+
+	resp, err := c.get(ctx, u, header)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && prev != nil {
+		c.add(u, prev, nil)
+		return prev, nil
+	}
+
+	// deleted pages are dropped from the index
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, errSkipped
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", u, resp.Status)
+	}
+
+	// redirects may leave the scope
+	final := resp.Request.URL
+
+	if !c.hosts[final.Host] {
+		return nil, errSkipped
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	isHTML := contentType == "text/html" || contentType == "application/xhtml+xml"
+
+	if !isHTML && !indexer.Supported(final.Path, c.files.Extensions) {
+		return nil, errSkipped
+	}
+
+	data, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r := &response{
+		etag:     resp.Header.Get("ETag"),
+		modified: resp.Header.Get("Last-Modified"),
+	}
+
+	link := u
+
+	if isHTML {
+		if r.page, err = parsePage(bytes.NewReader(data), final); err != nil {
+			return nil, err
+		}
+
+		if canonical, err := parseURL(r.page.canonical); err == nil && c.hosts[canonical.Host] {
+			link = canonical.String()
+		}
+	}
+
+	r.item = indexer.Item{
+		Path: itemPath(link, isHTML),
+
+		Revision: revision(link, r.etag, r.modified, data),
+
+		Size: int64(len(data)),
+		Link: link,
+
+		Metadata: index.Metadata{
+			"url": link,
+		},
+	}
+
+	if r.page != nil {
+		r.item.Title = r.page.title
+	}
+
+	if t, err := http.ParseTime(r.modified); err == nil {
+		r.item.Time = t
+	}
+
+	// unchanged content is usually not read again by the indexer
+	if prev != nil && prev.item.Revision == r.item.Revision {
+		data = nil
+	}
+
+	c.add(u, r, data)
+	return r, nil
+}
+
+// add records the response of u and its content, if any; its item can be
+// opened right away
+func (c *crawl) add(u string, r *response, data []byte) {
+	c.pages[u] = r
+	c.urls[r.item.Path] = u
+
+	c.mu.Lock()
+	c.Source.urls[r.item.Path] = u
+
+	if data != nil {
+		c.Source.bodies[r.item.Path] = data
+	}
+
+	c.mu.Unlock()
+}
+
+// sitemap returns the page URLs of a sitemap, following sitemap indexes
+func (c *crawl) sitemap(ctx context.Context, u string, depth int) ([]string, error) {
+	resp, err := c.get(ctx, u, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", u, resp.Status)
+	}
+
+	s, err := parseSitemap(resp.Body)
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", u, err)
+	}
+
+	result := s.urls()
+
+	// sitemap indexes are not nested deeper in practice
+	if depth < 2 {
+		for _, m := range s.sitemaps() {
+			urls, err := c.sitemap(ctx, m, depth+1)
+
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, urls...)
+		}
+	}
+
+	return result, nil
+}
+
+// parseURL parses an absolute http(s) URL and drops its query and fragment
+func parseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("unsupported scheme: " + u.Scheme)
+	}
+
+	if u.Host == "" {
+		return nil, errors.New("missing host")
+	}
+
+	u.RawQuery = ""
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	return u, nil
+}
+
+// itemPath maps a URL to an item path below its host. Pages get an .html
+// name so their format is known to the extraction.
+func itemPath(raw string, isHTML bool) string {
+	u, err := url.Parse(raw)
+
+	if err != nil {
+		return "/" + raw
+	}
+
+	p := u.Path
+
+	if p == "" || strings.HasSuffix(p, "/") {
+		p += "index.html"
+	} else if ext := strings.ToLower(path.Ext(p)); isHTML && ext != ".html" && ext != ".htm" {
+		p += ".html"
+	}
+
+	return "/" + u.Host + p
+}
+
+// revision derives a revision from the ETag or Last-Modified header, or
+// hashes the content if there are none
+func revision(link, etag, modified string, data []byte) string {
+	validator := etag
+
+	if validator == "" {
+		validator = modified
+	}
+
+	if validator == "" {
+		hash := md5.Sum(data)
+		return hex.EncodeToString(hash[:])
+	}
+
+	hash := md5.Sum([]byte(link + "\n" + validator))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,173 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/adrianliechti/wingman-index/pkg/indexer"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSource(t *testing.T) {
+	ctx := context.Background()
+
+	var server *httptest.Server
+
+	var intro, docs atomic.Int32
+
+	pages := map[string]string{
+		"/": `<html><head><title>Home</title></head><body>
+			<a href="/docs/">Docs</a>
+			<a href="/docs/intro#setup">Intro</a>
+			<a href="/private/secret">Secret</a>
+			<a href="/about?ref=home">About</a>
+			<a href="https://example.com/">External</a>
+		</body></html>`,
+
+		"/docs/": `<html><head><title>Docs</title><link rel="canonical" href="/docs/"></head><body>
+			<a href="intro">Intro</a>
+		</body></html>`,
+
+		"/docs/intro": `<html><head><title>Intro</title></head><body>
+			<a href="deep">Deep</a>
+		</body></html>`,
+
+		"/docs/deep": `<html><head><title>Deep</title></head></html>`,
+
+		"/docs/index.html": `<html><head><title>Docs</title><link rel="canonical" href="/docs/"></head></html>`,
+
+		"/about": `<html><head><title>About</title><meta name="robots" content="noindex"></head><body>
+			<a href="/docs/index.html">Docs</a>
+		</body></html>`,
+
+		"/private/secret": `<html><head><title>Secret</title></head></html>`,
+	}
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private/\n")
+			return
+
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<?xml version="1.0"?><urlset><url><loc>%[1]s/docs/deep</loc></url><url><loc>https://example.com/</loc></url></urlset>`, server.URL)
+			return
+
+		case "/docs/":
+			docs.Add(1)
+
+		case "/docs/intro":
+			intro.Add(1)
+
+			w.Header().Set("ETag", `"v1"`)
+
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		page, ok := pages[r.URL.Path]
+
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, page)
+	}))
+
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	s, err := New([]string{server.URL + "/"}, WithDepth(1), WithRate(0))
+	require.NoError(t, err)
+
+	items := collect(t, s)
+
+	require.Equal(t, []string{
+		"/" + host + "/docs/index.html",
+		"/" + host + "/docs/intro.html",
+		"/" + host + "/index.html",
+	}, slices.Sorted(maps.Keys(items)))
+
+	item := items["/"+host+"/docs/index.html"]
+
+	require.Equal(t, "Docs", item.Title)
+	require.Equal(t, server.URL+"/docs/", item.Link)
+	require.Equal(t, server.URL+"/docs/", item.Metadata["url"])
+
+	// the content fetched by the crawl is opened once without another request
+	for range 2 {
+		r, err := s.Open(ctx, item.Path)
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(r)
+		r.Close()
+
+		require.NoError(t, err)
+		require.Contains(t, string(data), "<title>Docs</title>")
+	}
+
+	require.Equal(t, int32(2), docs.Load())
+
+	// unchanged pages are answered with 304 and keep their revision
+	revision := items["/"+host+"/docs/intro.html"].Revision
+
+	items = collect(t, s)
+
+	require.Len(t, items, 3)
+	require.Equal(t, revision, items["/"+host+"/docs/intro.html"].Revision)
+	require.Equal(t, int32(2), intro.Load())
+
+	// sitemaps seed pages at depth 0 and are limited to their host
+	s, err = New(nil, WithSitemap(server.URL+"/sitemap.xml"), WithDepth(0), WithRate(0))
+	require.NoError(t, err)
+
+	items = collect(t, s)
+	require.Equal(t, []string{"/" + host + "/docs/deep.html"}, slices.Sorted(maps.Keys(items)))
+}
+
+func TestRobots(t *testing.T) {
+	r := parseRobots(strings.NewReader(`
+User-agent: other
+Disallow: /
+
+User-agent: *
+Disallow: /private/
+Disallow: /*.pdf$
+Allow: /private/public
+`), "wingman-index")
+
+	require.True(t, r.allowed("/docs/"))
+	require.False(t, r.allowed("/private/secret"))
+	require.True(t, r.allowed("/private/public/page"))
+	require.False(t, r.allowed("/files/manual.pdf"))
+	require.True(t, r.allowed("/files/manual.pdf.html"))
+
+	r = parseRobots(strings.NewReader("User-agent: wingman-index\nDisallow: /\n\nUser-agent: *\nDisallow:\n"), "wingman-index")
+
+	require.False(t, r.allowed("/docs/"))
+}
+
+func collect(t *testing.T, s *Source) map[string]indexer.Item {
+	result := map[string]indexer.Item{}
+
+	for item, err := range s.Items(context.Background()) {
+		require.NoError(t, err)
+		result[item.Path] = item
+	}
+
+	return result
+}
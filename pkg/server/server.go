@@ -25,6 +25,7 @@ func New(cfg *config.Config) *Server {
 	}
 
 	mcp.AddTool(s.server, QueryTool, s.Query)
+	mcp.AddTool(s.server, StatsTool, s.Stats)
 
 	return s
 }
@@ -17,6 +17,9 @@ var QueryTool = &mcp.Tool{
 
 type QueryParams struct {
 	Query string `json:"query" jsonschema:"The search query or question to find relevant information in the knowledge database. Use natural language and be specific about what information you're looking for."`
+
+	Language string `json:"language,omitempty" jsonschema:"Optional programming language to restrict the results to source code, e.g. go, python or typescript."`
+	Symbol   string `json:"symbol,omitempty" jsonschema:"Optional name of a function, method or type to restrict the results to its declaration, e.g. Server.Query."`
 }
 
 type QueryResult struct {
@@ -24,7 +27,7 @@ type QueryResult struct {
 	Source  string `json:"source,omitempty"`
 	Content string `json:"content,omitempty"`
 
-	Metadata map[string]string `json:"metadata,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
 }
 
 func (s *Server) Query(ctx context.Context, ss *mcp.ServerSession, req *mcp.CallToolParamsFor[QueryParams]) (*mcp.CallToolResultFor[any], error) {
@@ -34,6 +37,20 @@ func (s *Server) Query(ctx context.Context, ss *mcp.ServerSession, req *mcp.Call
 		Limit: to.Ptr(10),
 	}
 
+	filters := map[string]any{}
+
+	if req.Arguments.Language != "" {
+		filters["language"] = req.Arguments.Language
+	}
+
+	if req.Arguments.Symbol != "" {
+		filters["symbol"] = req.Arguments.Symbol
+	}
+
+	if len(filters) > 0 {
+		opts.Filters = filters
+	}
+
 	results, err := s.Index.Query(ctx, query, opts)
 
 	if err != nil {
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/adrianliechti/wingman-index/pkg/index"
+	"github.com/adrianliechti/wingman-index/pkg/utils"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var StatsTool = &mcp.Tool{
+	Name:        "describe_knowledge_database",
+	Title:       "Describe Knowledge Database",
+	Description: "Returns statistics about the knowledge database, such as the number of documents and sources, the embedding model and dimension, and which search features it supports.",
+}
+
+type StatsParams struct {
+}
+
+type StatsResult struct {
+	Documents int64 `json:"documents"`
+	Sources   int64 `json:"sources,omitempty"`
+
+	Model     string `json:"model,omitempty"`
+	Dimension int    `json:"dimension,omitempty"`
+
+	Size int64 `json:"size,omitempty"`
+
+	Capabilities *CapabilitiesResult `json:"capabilities,omitempty"`
+
+	EmbeddingCache *CacheResult `json:"embedding_cache,omitempty"`
+}
+
+type CapabilitiesResult struct {
+	Filters bool `json:"filters"`
+	Hybrid  bool `json:"hybrid"`
+	Paging  bool `json:"paging"`
+	Vectors bool `json:"vectors"`
+	Aliases bool `json:"aliases"`
+}
+
+type CacheResult struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+
+	Entries int `json:"entries"`
+}
+
+func (s *Server) Stats(ctx context.Context, ss *mcp.ServerSession, req *mcp.CallToolParamsFor[StatsParams]) (*mcp.CallToolResultFor[any], error) {
+	result := StatsResult{}
+
+	if p, ok := s.Index.(index.StatsProvider); ok {
+		stats, err := p.Stats(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result.Documents = stats.Documents
+		result.Sources = stats.Sources
+
+		result.Model = stats.Model
+		result.Dimension = stats.Dimension
+
+		result.Size = stats.Size
+	}
+
+	if p, ok := s.Index.(index.CapabilitiesProvider); ok {
+		caps := p.Capabilities()
+
+		result.Capabilities = &CapabilitiesResult{
+			Filters: caps.Filters,
+			Hybrid:  caps.Hybrid,
+			Paging:  caps.Paging,
+			Vectors: caps.Vectors,
+			Aliases: caps.Aliases,
+		}
+	}
+
+	if e, ok := s.Embedder.(*utils.CachingEmbedder); ok {
+		stats := e.Stats()
+
+		result.EmbeddingCache = &CacheResult{
+			Hits:   stats.Hits,
+			Misses: stats.Misses,
+
+			Entries: stats.Entries,
+		}
+	}
+
+	data, _ := json.Marshal(result)
+
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: string(data),
+			},
+		},
+	}, nil
+}
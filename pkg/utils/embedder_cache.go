@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/adrianliechti/wingman/pkg/provider"
+)
+
+// CachingEmbedder caches embeddings by model and text in a in-memory LRU and
+// optionally on disk
+type CachingEmbedder struct {
+	embedder provider.Embedder
+
+	model string
+
+	dir  string
+	size int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+type CacheStats struct {
+	Hits   int64
+	Misses int64
+
+	Entries int
+}
+
+type cacheEntry struct {
+	Key string `json:"-"`
+
+	Model     string    `json:"model"`
+	Embedding []float32 `json:"embedding"`
+}
+
+// NewCachingEmbedder keeps up to size embeddings of model in memory. If dir is
+// not empty, embeddings are persisted there as well.
+func NewCachingEmbedder(embedder provider.Embedder, model string, size int, dir string) *CachingEmbedder {
+	return &CachingEmbedder{
+		embedder: embedder,
+
+		model: model,
+
+		dir:  dir,
+		size: size,
+
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (e *CachingEmbedder) Embed(ctx context.Context, texts []string) (*provider.Embedding, error) {
+	result := &provider.Embedding{
+		Embeddings: make([][]float32, len(texts)),
+	}
+
+	var missing []int
+	var missingTexts []string
+
+	for i, text := range texts {
+		entry, ok := e.get(e.key(text))
+
+		if !ok {
+			missing = append(missing, i)
+			missingTexts = append(missingTexts, text)
+
+			continue
+		}
+
+		if result.Model == "" {
+			result.Model = entry.Model
+		}
+
+		result.Embeddings[i] = entry.Embedding
+	}
+
+	e.hits.Add(int64(len(texts) - len(missing)))
+	e.misses.Add(int64(len(missing)))
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	embedding, err := e.embedder.Embed(ctx, missingTexts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(embedding.Embeddings) != len(missing) {
+		return nil, fmt.Errorf("embedder returned %d embeddings for %d texts", len(embedding.Embeddings), len(missing))
+	}
+
+	if embedding.Model != "" {
+		result.Model = embedding.Model
+	}
+
+	for j, i := range missing {
+		result.Embeddings[i] = embedding.Embeddings[j]
+
+		e.put(&cacheEntry{
+			Key: e.key(texts[i]),
+
+			Model:     embedding.Model,
+			Embedding: embedding.Embeddings[j],
+		})
+	}
+
+	return result, nil
+}
+
+// Stats returns the hit and miss counters of the cache
+func (e *CachingEmbedder) Stats() CacheStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return CacheStats{
+		Hits:   e.hits.Load(),
+		Misses: e.misses.Load(),
+
+		Entries: e.order.Len(),
+	}
+}
+
+func (e *CachingEmbedder) key(text string) string {
+	hash := sha256.Sum256([]byte(e.model + "\x00" + text))
+	return hex.EncodeToString(hash[:])
+}
+
+func (e *CachingEmbedder) get(key string) (*cacheEntry, bool) {
+	e.mu.Lock()
+
+	if elem, ok := e.entries[key]; ok {
+		e.order.MoveToFront(elem)
+		e.mu.Unlock()
+
+		return elem.Value.(*cacheEntry), true
+	}
+
+	e.mu.Unlock()
+
+	if e.dir == "" {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(e.path(key))
+
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+
+	entry.Key = key
+
+	e.store(&entry)
+
+	return &entry, true
+}
+
+func (e *CachingEmbedder) put(entry *cacheEntry) {
+	e.store(entry)
+
+	if e.dir == "" {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+
+	if err != nil {
+		return
+	}
+
+	path := e.path(entry.Key)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+
+	os.WriteFile(path, data, 0644)
+}
+
+func (e *CachingEmbedder) store(entry *cacheEntry) {
+	if e.size <= 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if elem, ok := e.entries[entry.Key]; ok {
+		elem.Value = entry
+		e.order.MoveToFront(elem)
+
+		return
+	}
+
+	e.entries[entry.Key] = e.order.PushFront(entry)
+
+	for e.order.Len() > e.size {
+		last := e.order.Back()
+
+		e.order.Remove(last)
+		delete(e.entries, last.Value.(*cacheEntry).Key)
+	}
+}
+
+func (e *CachingEmbedder) path(key string) string {
+	return filepath.Join(e.dir, key[0:2], key+".json")
+}
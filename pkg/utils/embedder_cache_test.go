@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adrianliechti/wingman/pkg/provider"
+
+	"github.com/stretchr/testify/require"
+)
+
+type countingEmbedder struct {
+	texts []string
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, texts []string) (*provider.Embedding, error) {
+	e.texts = append(e.texts, texts...)
+
+	vectors := make([][]float32, len(texts))
+
+	for i, text := range texts {
+		vectors[i] = []float32{float32(len(text))}
+	}
+
+	return &provider.Embedding{Model: "test", Embeddings: vectors}, nil
+}
+
+func TestCachingEmbedder(t *testing.T) {
+	ctx := context.Background()
+
+	inner := &countingEmbedder{}
+	e := NewCachingEmbedder(inner, "test", 2, "")
+
+	result, err := e.Embed(ctx, []string{"a", "bb"})
+	require.NoError(t, err)
+	require.Equal(t, [][]float32{{1}, {2}}, result.Embeddings)
+
+	result, err = e.Embed(ctx, []string{"bb", "ccc"})
+	require.NoError(t, err)
+	require.Equal(t, "test", result.Model)
+	require.Equal(t, [][]float32{{2}, {3}}, result.Embeddings)
+	require.Equal(t, []string{"a", "bb", "ccc"}, inner.texts)
+
+	// "a" was evicted by "ccc"
+	_, err = e.Embed(ctx, []string{"a"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "bb", "ccc", "a"}, inner.texts)
+
+	stats := e.Stats()
+	require.Equal(t, int64(1), stats.Hits)
+	require.Equal(t, int64(4), stats.Misses)
+	require.Equal(t, 2, stats.Entries)
+}
+
+func TestCachingEmbedderDisk(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	inner := &countingEmbedder{}
+
+	_, err := NewCachingEmbedder(inner, "test", 10, dir).Embed(ctx, []string{"a"})
+	require.NoError(t, err)
+
+	result, err := NewCachingEmbedder(inner, "test", 10, dir).Embed(ctx, []string{"a"})
+	require.NoError(t, err)
+	require.Equal(t, "test", result.Model)
+	require.Equal(t, [][]float32{{1}}, result.Embeddings)
+	require.Equal(t, []string{"a"}, inner.texts)
+
+	// a different model does not share cached embeddings
+	_, err = NewCachingEmbedder(inner, "other", 10, dir).Embed(ctx, []string{"a"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "a"}, inner.texts)
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type EmbedderOption func(*embedderConfig)
+
+type embedderConfig struct {
+	client *http.Client
+
+	token string
+
+	dimensions int
+	batchSize  int
+}
+
+func newEmbedderConfig(options ...EmbedderOption) *embedderConfig {
+	c := &embedderConfig{
+		client: http.DefaultClient,
+
+		batchSize: 64,
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+func WithClient(client *http.Client) EmbedderOption {
+	return func(c *embedderConfig) {
+		c.client = client
+	}
+}
+
+func WithToken(token string) EmbedderOption {
+	return func(c *embedderConfig) {
+		c.token = token
+	}
+}
+
+// WithDimensions requests embeddings shortened to the given dimension
+func WithDimensions(dimensions int) EmbedderOption {
+	return func(c *embedderConfig) {
+		c.dimensions = dimensions
+	}
+}
+
+// WithBatchSize limits the number of texts sent per request
+func WithBatchSize(size int) EmbedderOption {
+	return func(c *embedderConfig) {
+		c.batchSize = size
+	}
+}
+
+func (c *embedderConfig) post(ctx context.Context, url string, body, result any) error {
+	data, err := json.Marshal(body)
+
+	if err != nil {
+		return err
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return convertError(resp)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// statusError is returned for unsuccessful responses of embedding APIs
+type statusError struct {
+	StatusCode int
+
+	// RetryAfter is the delay requested by the Retry-After header, if any
+	RetryAfter time.Duration
+
+	Text string
+}
+
+func (e *statusError) Error() string {
+	if e.Text == "" {
+		return http.StatusText(e.StatusCode)
+	}
+
+	return fmt.Sprintf("%s: %s", http.StatusText(e.StatusCode), e.Text)
+}
+
+func convertError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	return &statusError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: retryAfter(resp.Header),
+
+		Text: strings.TrimSpace(string(data)),
+	}
+}
+
+// retryAfter parses the Retry-After header given in seconds or as HTTP date
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0)
+	}
+
+	return 0
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAIEmbedder(t *testing.T) {
+	var requests []openaiEmbeddingRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/embeddings", r.URL.Path)
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var req openaiEmbeddingRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		requests = append(requests, req)
+
+		var resp openaiEmbeddingResponse
+		resp.Model = req.Model
+
+		// answer in reverse order to check that results are sorted by index
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			resp.Data = append(resp.Data, openaiEmbedding{
+				Index:     i,
+				Embedding: []float32{float32(len(req.Input[i])), float32(req.Dimensions)},
+			})
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}))
+
+	defer server.Close()
+
+	e, err := NewOpenAIEmbedder(server.URL+"/v1/", "text-embedding-3-small", WithToken("secret"), WithDimensions(256), WithBatchSize(2))
+	require.NoError(t, err)
+
+	result, err := e.Embed(context.Background(), []string{"a", "bb", "ccc"})
+	require.NoError(t, err)
+
+	require.Equal(t, "text-embedding-3-small", result.Model)
+	require.Equal(t, [][]float32{{1, 256}, {2, 256}, {3, 256}}, result.Embeddings)
+
+	require.Len(t, requests, 2)
+	require.Equal(t, []string{"a", "bb"}, requests[0].Input)
+	require.Equal(t, []string{"ccc"}, requests[1].Input)
+}
+
+func TestOpenAIEmbedderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+
+	defer server.Close()
+
+	e, err := NewOpenAIEmbedder(server.URL, "text-embedding-3-small")
+	require.NoError(t, err)
+
+	_, err = e.Embed(context.Background(), []string{"a"})
+	require.ErrorContains(t, err, "rate limited")
+
+	retry, delay := retryable(err)
+	require.True(t, retry)
+	require.Equal(t, 3*time.Second, delay)
+}
+
+func TestOllamaEmbedder(t *testing.T) {
+	var requests []ollamaEmbedRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/embed", r.URL.Path)
+
+		var req ollamaEmbedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		requests = append(requests, req)
+
+		resp := ollamaEmbedResponse{
+			Model: req.Model,
+		}
+
+		for range req.Input {
+			resp.Embeddings = append(resp.Embeddings, []float32{3, 4, 12})
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}))
+
+	defer server.Close()
+
+	e, err := NewOllamaEmbedder(server.URL, "nomic-embed-text", WithDimensions(2), WithBatchSize(1))
+	require.NoError(t, err)
+
+	result, err := e.Embed(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+
+	require.Equal(t, "nomic-embed-text", result.Model)
+	require.Equal(t, [][]float32{{0.6, 0.8}, {0.6, 0.8}}, result.Embeddings)
+	require.Len(t, requests, 2)
+}
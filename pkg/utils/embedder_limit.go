@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/adrianliechti/wingman/pkg/provider"
+
+	"github.com/openai/openai-go"
+	"golang.org/x/time/rate"
+)
+
+// LimitedEmbedder throttles requests to an embedder and retries requests
+// failing temporarily with exponential backoff
+type LimitedEmbedder struct {
+	embedder provider.Embedder
+
+	limiter *rate.Limiter
+
+	retries int
+	backoff time.Duration
+}
+
+// NewLimitedEmbedder allows limit requests per second (unlimited if zero) and
+// retries each request up to retries times
+func NewLimitedEmbedder(embedder provider.Embedder, limit float64, retries int) *LimitedEmbedder {
+	limiter := rate.NewLimiter(rate.Inf, 0)
+
+	if limit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(limit), 1)
+	}
+
+	return &LimitedEmbedder{
+		embedder: embedder,
+
+		limiter: limiter,
+
+		retries: retries,
+		backoff: time.Second,
+	}
+}
+
+func (e *LimitedEmbedder) Embed(ctx context.Context, texts []string) (*provider.Embedding, error) {
+	backoff := e.backoff
+
+	for attempt := 0; ; attempt++ {
+		if err := e.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		result, err := e.embedder.Embed(ctx, texts)
+
+		if err == nil {
+			return result, nil
+		}
+
+		retry, delay := retryable(err)
+
+		if attempt >= e.retries || !retry {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(max(backoff, delay)):
+		}
+
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+// retryable reports whether a request failed temporarily, i.e. it was rate
+// limited, the server failed or the connection broke, and the delay requested
+// by the server
+func retryable(err error) (bool, time.Duration) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false, 0
+	}
+
+	var status *statusError
+
+	if errors.As(err, &status) {
+		return retryableStatus(status.StatusCode), status.RetryAfter
+	}
+
+	// errors of the wingman client
+	var apierr *openai.Error
+
+	if errors.As(err, &apierr) {
+		var delay time.Duration
+
+		if apierr.Response != nil {
+			delay = retryAfter(apierr.Response.Header)
+		}
+
+		return retryableStatus(apierr.StatusCode), delay
+	}
+
+	var neterr net.Error
+
+	if errors.As(err, &neterr) {
+		return true, 0
+	}
+
+	return errors.Is(err, io.ErrUnexpectedEOF), 0
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/adrianliechti/wingman/pkg/provider"
+
+	"github.com/stretchr/testify/require"
+)
+
+type flakyEmbedder struct {
+	failures int
+	calls    int
+
+	// err is returned by the failing calls
+	err error
+}
+
+func (e *flakyEmbedder) Embed(ctx context.Context, texts []string) (*provider.Embedding, error) {
+	e.calls++
+
+	if e.calls <= e.failures {
+		if e.err != nil {
+			return nil, e.err
+		}
+
+		return nil, &statusError{StatusCode: http.StatusTooManyRequests}
+	}
+
+	return &provider.Embedding{Embeddings: make([][]float32, len(texts))}, nil
+}
+
+func TestLimitedEmbedderRetries(t *testing.T) {
+	flaky := &flakyEmbedder{failures: 2}
+
+	e := NewLimitedEmbedder(flaky, 0, 3)
+	e.backoff = time.Millisecond
+
+	result, err := e.Embed(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	require.Len(t, result.Embeddings, 2)
+	require.Equal(t, 3, flaky.calls)
+}
+
+func TestLimitedEmbedderGivesUp(t *testing.T) {
+	flaky := &flakyEmbedder{failures: 10}
+
+	e := NewLimitedEmbedder(flaky, 0, 2)
+	e.backoff = time.Millisecond
+
+	_, err := e.Embed(context.Background(), []string{"a"})
+	require.Error(t, err)
+	require.Equal(t, 3, flaky.calls)
+}
+
+func TestLimitedEmbedderPermanentErrors(t *testing.T) {
+	for _, err := range []error{
+		&statusError{StatusCode: http.StatusBadRequest},
+		&statusError{StatusCode: http.StatusUnauthorized},
+		errors.New("unexpected number of embeddings"),
+	} {
+		flaky := &flakyEmbedder{failures: 10, err: err}
+
+		e := NewLimitedEmbedder(flaky, 0, 3)
+		e.backoff = time.Millisecond
+
+		_, result := e.Embed(context.Background(), []string{"a"})
+		require.Equal(t, err, result)
+		require.Equal(t, 1, flaky.calls)
+	}
+}
+
+func TestLimitedEmbedderRetryAfter(t *testing.T) {
+	flaky := &flakyEmbedder{failures: 1, err: &statusError{StatusCode: http.StatusServiceUnavailable, RetryAfter: 50 * time.Millisecond}}
+
+	e := NewLimitedEmbedder(flaky, 0, 3)
+	e.backoff = time.Millisecond
+
+	start := time.Now()
+
+	_, err := e.Embed(context.Background(), []string{"a"})
+	require.NoError(t, err)
+	require.Equal(t, 2, flaky.calls)
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/adrianliechti/wingman/pkg/provider"
+)
+
+// OllamaEmbedder uses the /api/embed endpoint of an Ollama server
+type OllamaEmbedder struct {
+	*embedderConfig
+
+	url   string
+	model string
+}
+
+func NewOllamaEmbedder(url, model string, options ...EmbedderOption) (*OllamaEmbedder, error) {
+	if url == "" {
+		url = "http://localhost:11434"
+	}
+
+	if model == "" {
+		return nil, errors.New("model is required")
+	}
+
+	return &OllamaEmbedder{
+		embedderConfig: newEmbedderConfig(options...),
+
+		url:   strings.TrimRight(url, "/"),
+		model: model,
+	}, nil
+}
+
+func (e *OllamaEmbedder) Embed(ctx context.Context, texts []string) (*provider.Embedding, error) {
+	result := &provider.Embedding{
+		Model: e.model,
+	}
+
+	for batch := range slices.Chunk(texts, max(1, e.batchSize)) {
+		body := ollamaEmbedRequest{
+			Model: e.model,
+			Input: batch,
+		}
+
+		var response ollamaEmbedResponse
+
+		if err := e.post(ctx, e.url+"/api/embed", body, &response); err != nil {
+			return nil, err
+		}
+
+		if len(response.Embeddings) != len(batch) {
+			return nil, errors.New("unexpected number of embeddings")
+		}
+
+		if response.Model != "" {
+			result.Model = response.Model
+		}
+
+		for _, embedding := range response.Embeddings {
+			if e.dimensions > 0 && e.dimensions < len(embedding) {
+				embedding = truncateEmbedding(embedding, e.dimensions)
+			}
+
+			result.Embeddings = append(result.Embeddings, embedding)
+		}
+	}
+
+	return result, nil
+}
+
+// truncateEmbedding shortens a (matryoshka) embedding and normalizes it again
+func truncateEmbedding(embedding []float32, dimensions int) []float32 {
+	result := slices.Clone(embedding[:dimensions])
+
+	var norm float64
+
+	for _, v := range result {
+		norm += float64(v) * float64(v)
+	}
+
+	if norm == 0 {
+		return result
+	}
+
+	norm = math.Sqrt(norm)
+
+	for i, v := range result {
+		result[i] = float32(float64(v) / norm)
+	}
+
+	return result
+}
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Model string `json:"model"`
+
+	Embeddings [][]float32 `json:"embeddings"`
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/adrianliechti/wingman/pkg/provider"
+)
+
+// OpenAIEmbedder uses the embeddings API of OpenAI or any compatible server
+type OpenAIEmbedder struct {
+	*embedderConfig
+
+	url   string
+	model string
+}
+
+func NewOpenAIEmbedder(url, model string, options ...EmbedderOption) (*OpenAIEmbedder, error) {
+	if url == "" {
+		url = "https://api.openai.com/v1"
+	}
+
+	if model == "" {
+		return nil, errors.New("model is required")
+	}
+
+	return &OpenAIEmbedder{
+		embedderConfig: newEmbedderConfig(options...),
+
+		url:   strings.TrimRight(url, "/"),
+		model: model,
+	}, nil
+}
+
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) (*provider.Embedding, error) {
+	result := &provider.Embedding{
+		Model: e.model,
+	}
+
+	for batch := range slices.Chunk(texts, max(1, e.batchSize)) {
+		body := openaiEmbeddingRequest{
+			Model: e.model,
+			Input: batch,
+
+			Dimensions: e.dimensions,
+		}
+
+		var response openaiEmbeddingResponse
+
+		if err := e.post(ctx, e.url+"/embeddings", body, &response); err != nil {
+			return nil, err
+		}
+
+		if len(response.Data) != len(batch) {
+			return nil, errors.New("unexpected number of embeddings")
+		}
+
+		if response.Model != "" {
+			result.Model = response.Model
+		}
+
+		embeddings := make([][]float32, len(batch))
+
+		for _, d := range response.Data {
+			if d.Index < 0 || d.Index >= len(batch) {
+				return nil, errors.New("invalid embedding index")
+			}
+
+			embeddings[d.Index] = d.Embedding
+		}
+
+		result.Embeddings = append(result.Embeddings, embeddings...)
+	}
+
+	return result, nil
+}
+
+type openaiEmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+
+	Dimensions int `json:"dimensions,omitempty"`
+}
+
+type openaiEmbeddingResponse struct {
+	Model string `json:"model"`
+
+	Data []openaiEmbedding `json:"data"`
+}
+
+type openaiEmbedding struct {
+	Index     int       `json:"index"`
+	Embedding []float32 `json:"embedding"`
+}